@@ -1,275 +1,1528 @@
 package mapper
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"maps"
+	"math"
+	"net/url"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/pkg/errors"
 	reflectutils "github.com/raunlo/pgx-with-automapper/reflect_utils"
 	ordered_map "github.com/wk8/go-ordered-map/v2"
 )
 
 var (
-	ErrNoRows = errors.New("no rows found")
+	// ErrNoRows is returned when rows hold no entity. It wraps pgx.ErrNoRows, so errors.Is matches both of them.
+	ErrNoRows error = noRowsError{}
+	// ErrTooManyRows matches TooManyRowsError with errors.Is
+	ErrTooManyRows = errors.New("too many rows")
 )
 
+type noRowsError struct{}
+
+func (noRowsError) Error() string { return "no rows found" }
+
+func (noRowsError) Unwrap() error { return pgx.ErrNoRows }
+
+// TooManyRowsError is returned when rows hold more than one entity where only one is expected, e.g. in ScanOne or
+// in oneToOne relationship
+type TooManyRowsError struct {
+	EntityType reflect.Type // type of the entity which had too many rows
+}
+
+func (e *TooManyRowsError) Error() string {
+	return fmt.Sprintf("Too many rows for entity(name=%s)", e.EntityType)
+}
+
+// Is reports whether target is ErrTooManyRows
+func (e *TooManyRowsError) Is(target error) bool {
+	return target == ErrTooManyRows
+}
+
 func getTooManyRowsError(entityType reflect.Type) error {
-	return errors.New(fmt.Sprintf("Too many rows for entity(name=%s)", entityType))
+	return &TooManyRowsError{EntityType: entityType}
 }
 
-func analyzeEntityGraphs(entityType reflect.Type) {
+// analysisMutex serializes analysis of entity graphs. While an entity graph is analyzed, its entities are stored with
+// nil mapping info to break recursion, so concurrent analysis must not observe or overwrite them.
+var analysisMutex sync.Mutex
+
+// analyzedEntities holds mapping info of the entities analyzed by the running analysis, guarded by analysisMutex
+var analyzedEntities []*MappingInfo
+
+// analyzeEntityGraph analyzes the entity graph and returns mapping info of the entity read under the same lock, so
+// that concurrent invalidation cannot remove it in between
+func analyzeEntityGraph(entityType reflect.Type) (*MappingInfo, error) {
+	analysisMutex.Lock()
+	defer analysisMutex.Unlock()
 	err := analyzeEntity(entityType)
+	// graph columns are collected once the whole graph is analyzed, as relationships back to the entities being
+	// analyzed have no mapping info before
+	for _, analyzedMappingInfo := range analyzedEntities {
+		analyzedMappingInfo.GraphColumns = make(map[string]struct{})
+		collectGraphColumns(analyzedMappingInfo, "", analyzedMappingInfo.GraphColumns, make(map[*MappingInfo]struct{}))
+	}
+	analyzedEntities = nil
 	if err != nil {
+		return nil, err
+	}
+	entityMappingInfo, _ := GetEntityGraphMappingInfo(reflectutils.DeReferencePointer(entityType))
+	return entityMappingInfo, nil
+}
+
+// RegisterEntity analyzes the entity graph of given type and caches its mapping info, so that malformed entity
+// definitions are reported at startup instead of on the first query
+func RegisterEntity(entityType reflect.Type) error {
+	if entityType == nil {
+		return errors.New("entity type cannot be nil")
+	}
+	entityType = reflectutils.DeReferencePointer(entityType)
+	if entityType.Kind() != reflect.Struct {
+		return errors.New(fmt.Sprintf("entity(%s) must be a struct", entityType))
+	}
+	_, err := analyzeEntityGraph(entityType)
+	return err
+}
+
+// MustAnalyze analyzes the entity graph of given type and panics when it is malformed. It is meant to be called
+// during initialization to catch invalid entity definitions before the first query.
+func MustAnalyze(entityType reflect.Type) {
+	if err := RegisterEntity(entityType); err != nil {
 		panic(err)
 	}
 }
 
-func analyzeEntity(currentType reflect.Type) error {
-	var fieldMapping = make(map[string]int)
-	var relationships = make(map[int]reflect.Type)
-	var keyField *PrimaryKeyInfo
-	if _, exists := GetEntityGraphMappingInfo(currentType); exists {
-		return nil
+// parseTag splits a tag value into the column name and the comma separated options following it
+func parseTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+func parseColumnOptions(field reflect.StructField, options []string) ColumnOptions {
+	columnOptions := ColumnOptions{format: field.Tag.Get("format")}
+	for _, option := range options {
+		switch option {
+		case "jsonb":
+			columnOptions.jsonb = true
+		case "generated", "auto":
+			columnOptions.generated = true
+		case "join":
+			columnOptions.join = true
+		case "composite":
+			columnOptions.composite = true
+		}
+	}
+	return columnOptions
+}
+
+// getMappingInfo returns mapping info of the entity, analyzing the entity graph when it is used for the first time
+func getMappingInfo(entityType reflect.Type) (*MappingInfo, error) {
+	entityMappingInfo, mappingInfoExists := GetEntityGraphMappingInfo(entityType)
+	// nil mapping info means that the entity graph is being analyzed by another goroutine, in which case
+	// analyzeEntityGraph waits for it to finish
+	if !mappingInfoExists || entityMappingInfo == nil {
+		var err error
+		if entityMappingInfo, err = analyzeEntityGraph(entityType); err != nil {
+			return nil, err
+		}
+	}
+	if entityMappingInfo == nil {
+		return nil, errors.New(fmt.Sprintf("no mapping info found for entity(%s)", entityType))
 	}
+	return entityMappingInfo, nil
+}
 
+func analyzeEntity(currentType reflect.Type) (err error) {
 	currentType = reflectutils.DeReferencePointer(currentType)
+	if _, exists := GetEntityGraphMappingInfo(currentType); exists {
+		return nil
+	}
 
 	// set dummy value to avoid infinite recursion
 	SetEntityGraphMappingInfo(currentType, nil)
-	for index := 0; index < currentType.NumField(); index++ {
+	defer func() {
+		if err != nil {
+			// remove dummy value, so that malformed entity is not treated as analyzed
+			globalEntityGraphMappingInfo.Delete(currentType)
+		}
+	}()
+
+	mappingInfo := &MappingInfo{
+		FieldMapping:         make(map[string][]int),
+		ColumnOptions:        make(map[string]ColumnOptions),
+		Relationships:        make(map[int]reflect.Type),
+		RelationshipPrefixes: make(map[int]string),
+		RelationshipKeys:     make(map[int]string),
+		JSONRelationships:    make(map[int]string),
+	}
+	var derivedColumns = make(map[string][]int)
+	if err := analyzeFields(currentType, nil, mappingInfo, derivedColumns); err != nil {
+		return err
+	}
+
+	// explicitly tagged columns take precedence over the ones derived from field names
+	for columnName, index := range derivedColumns {
+		if _, exists := mappingInfo.FieldMapping[columnName]; !exists {
+			mappingInfo.FieldMapping[columnName] = index
+		}
+	}
+
+	if err := checkDuplicateRelationships(currentType, mappingInfo); err != nil {
+		return err
+	}
+	if err := checkAmbiguousColumns(currentType, mappingInfo); err != nil {
+		return err
+	}
+
+	SetEntityGraphMappingInfo(currentType, mappingInfo)
+	analyzedEntities = append(analyzedEntities, mappingInfo)
+	return nil
+}
 
-		field := currentType.Field(index)
+// checkDuplicateRelationships returns error when the entity has multiple one-to-one relationships of the same type
+// with the same column prefix, e.g. CreatedBy and UpdatedBy users, as they would be mapped from the same columns
+func checkDuplicateRelationships(entityType reflect.Type, entityMappingInfo *MappingInfo) error {
+	type relationshipColumns struct {
+		relationshipType reflect.Type
+		prefix           string
+	}
+	fieldNames := make(map[relationshipColumns]string)
+	for index := 0; index < entityType.NumField(); index++ {
+		relationshipType, isRelationship := entityMappingInfo.Relationships[index]
+		if !isRelationship || reflectutils.DeReferencePointer(relationshipType).Kind() == reflect.Slice {
+			continue
+		}
+		columns := relationshipColumns{reflectutils.DeReferencePointer(relationshipType), entityMappingInfo.RelationshipPrefixes[index]}
+		if fieldName, exists := fieldNames[columns]; exists {
+			return errors.New(fmt.Sprintf("relationships %s and %s of entity(%s) are mapped from the same columns, use prefix tag to tell them apart",
+				fieldName, entityType.Field(index).Name, entityType))
+		}
+		fieldNames[columns] = entityType.Field(index).Name
+	}
+	return nil
+}
+
+// columnOwner is the entity which maps a column of the entity graph
+type columnOwner struct {
+	entityType reflect.Type
+	key        bool // column is primary key of the entity
+	composite  bool // column is part of composite primary key, which usually consists of foreign keys
+}
+
+// checkAmbiguousColumns returns error when two different entities of the entity graph map the same column, as all
+// entities are mapped from the same row and would get each other's values. Column may be shared when it is the primary
+// key of one entity only, as it is then the foreign key referencing it, e.g. customer_id of an order and of its
+// customer, or when it is part of composite primary key.
+func checkAmbiguousColumns(entityType reflect.Type, entityMappingInfo *MappingInfo) error {
+	return collectColumnOwners(entityType, entityMappingInfo, "", "", make(map[string]columnOwner), make(map[*MappingInfo]struct{}))
+}
+
+// collectColumnOwners collects owners of the columns of the entity graph. Primary key of entity sharing the key of its
+// parent with key tag is read from the parent's column, so the entity does not own sharedKeyColumn.
+func collectColumnOwners(entityType reflect.Type, entityMappingInfo *MappingInfo, prefix string, sharedKeyColumn string,
+	owners map[string]columnOwner, visited map[*MappingInfo]struct{}) error {
+	if _, seen := visited[entityMappingInfo]; seen {
+		return nil
+	}
+	visited[entityMappingInfo] = struct{}{}
+	defer delete(visited, entityMappingInfo)
+
+	var keyColumns []string
+	if entityMappingInfo.KeyField != nil {
+		keyColumns = entityMappingInfo.KeyField.columns()
+	}
+	for _, columnName := range orderedColumns(entityMappingInfo) {
+		if columnName == sharedKeyColumn {
+			continue
+		}
+		owner := columnOwner{
+			entityType: entityType,
+			key:        slices.Contains(keyColumns, columnName),
+			composite:  len(keyColumns) > 1 && slices.Contains(keyColumns, columnName),
+		}
+		existingOwner, exists := owners[prefix+columnName]
+		if !exists {
+			owners[prefix+columnName] = owner
+		} else if existingOwner.entityType != entityType && existingOwner.key == owner.key && !existingOwner.composite && !owner.composite {
+			return errors.New(fmt.Sprintf("column %s is mapped by both %s and %s, use prefix tag on the relationship to tell them apart",
+				prefix+columnName, existingOwner.entityType, entityType))
+		}
+	}
+
+	fieldIndexes := make([]int, 0, len(entityMappingInfo.Relationships))
+	for fieldIndex := range entityMappingInfo.Relationships {
+		fieldIndexes = append(fieldIndexes, fieldIndex)
+	}
+	sort.Ints(fieldIndexes)
+	for _, fieldIndex := range fieldIndexes {
+		relationshipType := reflectutils.DeReferencePointer(entityMappingInfo.Relationships[fieldIndex])
+		if relationshipType.Kind() == reflect.Slice {
+			relationshipType = reflectutils.DeReferencePointer(relationshipType.Elem())
+		}
+		if relationshipType == entityType {
+			// self-referential relationships are assembled from separate rows
+			continue
+		}
+		// entities being analyzed higher up in the entity graph have no mapping info yet
+		if relationshipMappingInfo, exists := GetEntityGraphMappingInfo(relationshipType); exists && relationshipMappingInfo != nil {
+			relationshipPrefix := prefix + entityMappingInfo.RelationshipPrefixes[fieldIndex]
+			var relationshipKeyColumn string
+			if _, sharesKey := entityMappingInfo.RelationshipKeys[fieldIndex]; sharesKey && relationshipMappingInfo.KeyField != nil {
+				relationshipKeyColumn = relationshipMappingInfo.KeyField.dbPrimaryKeyName
+			}
+			if err := collectColumnOwners(relationshipType, relationshipMappingInfo, relationshipPrefix, relationshipKeyColumn, owners, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// analyzeFields adds mappings of struct fields into mappingInfo. Fields of embedded structs are promoted into the
+// entity, so indexPrefix holds the index path of the embedded struct within the entity.
+func analyzeFields(structType reflect.Type, indexPrefix []int, mappingInfo *MappingInfo, derivedColumns map[string][]int) error {
+	for index := 0; index < structType.NumField(); index++ {
+
+		field := structType.Field(index)
+		fieldIndex := append(slices.Clone(indexPrefix), index)
 		dbTag := field.Tag.Get("db")
 		primaryKeyTag := field.Tag.Get("primaryKey")
 		relationshipTag := field.Tag.Get("relationship")
 
 		switch {
+		case dbTag == "-" || primaryKeyTag == "-" || relationshipTag == "-":
+			// field is explicitly excluded from mapping
+			continue
 		case primaryKeyTag != "":
-			if keyField == nil {
-				fieldMapping[primaryKeyTag] = index
-				keyField = &PrimaryKeyInfo{
-					dbPrimaryKeyName:          primaryKeyTag,
-					structPrimaryKeyFieldName: currentType.Field(index).Name,
+			columnName, options := parseTag(primaryKeyTag)
+			// fields tagged with composite option together form a composite primary key
+			isCompositeKey := slices.Contains(options, "composite")
+			options = slices.DeleteFunc(options, func(option string) bool { return option == "composite" })
+			if dbTag != "" {
+				// db tag names the key column, e.g. aliased key of a view, so primaryKey tag only marks the key
+				dbColumnName, dbOptions := parseTag(dbTag)
+				columnName = dbColumnName
+				options = append(options, dbOptions...)
+			}
+			switch {
+			case mappingInfo.KeyField == nil:
+				mappingInfo.KeyField = &PrimaryKeyInfo{
+					dbPrimaryKeyName:          columnName,
+					structPrimaryKeyFieldName: field.Name,
 				}
-			} else {
+				if isCompositeKey {
+					mappingInfo.KeyField.compositeColumns = []string{columnName}
+				}
+			case isCompositeKey && len(mappingInfo.KeyField.compositeColumns) > 0:
+				mappingInfo.KeyField.compositeColumns = append(mappingInfo.KeyField.compositeColumns, columnName)
+			default:
 				return errors.New("multiple primary key fields found")
 			}
+			mappingInfo.FieldMapping[columnName] = fieldIndex
+			mappingInfo.ColumnOptions[columnName] = parseColumnOptions(field, options)
 		case relationshipTag != "":
-			relationships[index] = field.Type
+			// embedded struct with relationship tag is a nested entity, so its fields are not promoted
+			if len(indexPrefix) > 0 {
+				return errors.New(fmt.Sprintf("relationship %s in embedded struct %s is not supported", field.Name, structType))
+			}
+			if _, relationshipOptions := parseTag(relationshipTag); field.Tag.Get("json") == "true" || slices.Contains(relationshipOptions, "json") {
+				if err := analyzeJSONRelationship(field, index, mappingInfo); err != nil {
+					return err
+				}
+				continue
+			}
+			mappingInfo.Relationships[index] = field.Type
+			if prefix := field.Tag.Get("prefix"); prefix != "" {
+				mappingInfo.RelationshipPrefixes[index] = prefix
+			}
 			var elementType = reflectutils.DeReferencePointer(field.Type)
 			if elementType.Kind() == reflect.Slice {
-				elementType = elementType.Elem()
+				elementType = reflectutils.DeReferencePointer(elementType.Elem())
 			}
 
 			err := analyzeEntity(elementType)
 			if err != nil {
 				return err
 			}
+			if keyColumn := field.Tag.Get("key"); keyColumn != "" {
+				// child shares primary key of the parent, so its key is read from the parent's column
+				if relationshipMappingInfo, _ := GetEntityGraphMappingInfo(elementType); relationshipMappingInfo != nil &&
+					(relationshipMappingInfo.KeyField == nil || len(relationshipMappingInfo.KeyField.compositeColumns) > 0) {
+					return errors.New(fmt.Sprintf("relationship %s with key tag requires single column primary key in %s", field.Name, elementType))
+				}
+				mappingInfo.RelationshipKeys[index] = keyColumn
+			}
 
 		case dbTag != "":
-			fieldMapping[dbTag] = index
+			columnName, options := parseTag(dbTag)
+			if columnName == "" && slices.Contains(options, "extra") {
+				if len(indexPrefix) > 0 {
+					return errors.New(fmt.Sprintf("extra field %s in embedded struct %s is not supported", field.Name, structType))
+				}
+				if field.Type.Kind() != reflect.Map || field.Type.Key().Kind() != reflect.String {
+					return errors.New(fmt.Sprintf("extra field %s must be a map with string keys, e.g. map[string]any", field.Name))
+				}
+				extraFieldIndex := index
+				mappingInfo.ExtraField = &extraFieldIndex
+				continue
+			}
+			mappingInfo.FieldMapping[columnName] = fieldIndex
+			mappingInfo.ColumnOptions[columnName] = parseColumnOptions(field, options)
 
-		}
-	}
+		case field.Anonymous && reflectutils.DeReferencePointer(field.Type).Kind() == reflect.Struct:
+			// promote fields of embedded struct, e.g. shared audit columns. Embedded struct pointer is allocated
+			// only when one of its columns has value.
+			if err := analyzeFields(reflectutils.DeReferencePointer(field.Type), fieldIndex, mappingInfo, derivedColumns); err != nil {
+				return err
+			}
 
-	mappingInfo := &MappingInfo{
-		KeyField:      keyField,
-		FieldMapping:  fieldMapping,
-		Relationships: relationships,
+		case field.IsExported() && NamingStrategy != nil:
+			derivedColumns[NamingStrategy(field.Name)] = fieldIndex
+		}
 	}
-	SetEntityGraphMappingInfo(currentType, mappingInfo)
 	return nil
 }
 
 // ScanOne scans rows into one object. Might need to scan multiple rows where there is one-to-many or one-to-one relationships
 func ScanOne(rows pgx.Rows, dest interface{}) error {
+	return ScanOneContext(context.Background(), rows, dest)
+}
+
+// ScanOneContext works like ScanOne, but stops scanning with the context error when ctx is cancelled
+func ScanOneContext(ctx context.Context, rows pgx.Rows, dest interface{}) error {
+	defer rows.Close()
+	destinationType, err := structDestinationType(dest)
+	if err != nil {
+		return err
+	}
+	entityMappingInfo, err := getMappingInfo(destinationType)
+	if err != nil {
+		return err
+	}
+
+	state := newScanState()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rowInMap, err := rowToMap(rows)
+		if err != nil {
+			return err
+		}
+		if skipsRow(entityMappingInfo, rowInMap) {
+			continue
+		}
+		_, err = mapToStruct(destinationType, rowInMap, state, dest)
+		if err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if reflect.ValueOf(dest).Elem().IsZero() {
+		return ErrNoRows
+	}
+	return nil
+}
+
+// ScanFirst scans the first entity from rows and ignores the rest, like LIMIT 1 would. Consecutive rows with the same
+// primary key are mapped into the first entity, so its relationships are complete. Rows are closed once the first row
+// of another entity is reached.
+func ScanFirst(rows pgx.Rows, dest interface{}) error {
 	defer rows.Close()
+	destinationType, err := structDestinationType(dest)
+	if err != nil {
+		return err
+	}
+	entityMappingInfo, err := getMappingInfo(destinationType)
+	if err != nil {
+		return err
+	}
+	if err := checkPrimaryKey(destinationType, entityMappingInfo); err != nil {
+		return err
+	}
+
+	state := newScanState()
+	var firstKey interface{}
+	mappedRows := 0
+	for rows.Next() {
+		rowInMap, err := rowToMap(rows)
+		if err != nil {
+			return err
+		}
+		if skipsRow(entityMappingInfo, rowInMap) {
+			continue
+		}
+
+		keyValue, _ := entityMappingInfo.KeyField.keyValue(rowInMap)
+		if mappedRows == 0 {
+			firstKey = keyValue
+		} else if keyValue != firstKey {
+			break
+		}
+		mappedRows++
+
+		if _, err = mapToStruct(destinationType, rowInMap, state, dest); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if reflect.ValueOf(dest).Elem().IsZero() {
+		return ErrNoRows
+	}
+	return nil
+}
+
+// structDestinationType validates that dest is a pointer to a struct and returns the struct type
+func structDestinationType(dest interface{}) (reflect.Type, error) {
 	destinationType := reflect.TypeOf(dest)
 	if destinationType == nil {
-		return errors.New("dest cannot be nil")
+		return nil, errors.New("dest cannot be nil")
 	}
 
 	if destinationType.Kind() != reflect.Ptr {
-		return errors.New("dest must be a pointer")
+		return nil, errors.New("dest must be a pointer")
 	}
 	// de-reference pointer
 	destinationType = reflectutils.DeReferencePointer(destinationType)
 
 	if destinationType.Kind() != reflect.Struct {
-		return errors.New("dest must be a pointer to a struct")
+		return nil, errors.New("dest must be a pointer to a struct")
+	}
+	return destinationType, nil
+}
+
+// ScanMany scans rows into a slice of objects.
+func ScanMany(rows pgx.Rows, dest interface{}) error {
+	return ScanManyContext(context.Background(), rows, dest)
+}
+
+// ScanManyContext works like ScanMany, but stops scanning with the context error when ctx is cancelled
+func ScanManyContext(ctx context.Context, rows pgx.Rows, dest interface{}) error {
+	return scanMany(ctx, rows, dest, false, 0)
+}
+
+// ScanManyCap works like ScanMany, but preallocates dest and the intermediate buffers for capacity entities. It is
+// meant for large result sets with known size, e.g. reports with LIMIT, to avoid growing the slice row by row.
+func ScanManyCap(rows pgx.Rows, dest interface{}, capacity int) error {
+	return scanMany(context.Background(), rows, dest, false, capacity)
+}
+
+// ScanManyAppend works like ScanMany, but appends the mapped entities to the existing elements of dest slice instead
+// of replacing them, e.g. to collect pages of results into one slice. Entities are not merged with the existing
+// elements, even when they have the same primary key.
+func ScanManyAppend(rows pgx.Rows, dest interface{}) error {
+	destinationPtrValue := reflect.ValueOf(dest)
+	if dest == nil || destinationPtrValue.Kind() != reflect.Ptr || destinationPtrValue.IsNil() {
+		rows.Close()
+		return errors.New("dest must be a non-nil pointer")
+	}
+	destinationValue := destinationPtrValue.Elem()
+	if destinationValue.Kind() != reflect.Slice {
+		rows.Close()
+		return errors.New("dest must be a slice")
+	}
+
+	scanned := reflect.New(destinationValue.Type())
+	if err := ScanMany(rows, scanned.Interface()); err != nil {
+		return err
+	}
+	destinationValue.Set(reflect.AppendSlice(destinationValue, scanned.Elem()))
+	return nil
+}
+
+// ScanManyLenient works like ScanMany, but rows which fail to map are skipped instead of failing the whole scan. Dest
+// holds the entities which were mapped and the returned error joins the errors of all skipped rows.
+func ScanManyLenient(rows pgx.Rows, dest interface{}) error {
+	return scanMany(context.Background(), rows, dest, true, 0)
+}
+
+// scanMany scans rows into dest slice preallocated for capacity entities. In lenient mode, mapping errors of rows are
+// collected instead of returned.
+func scanMany(ctx context.Context, rows pgx.Rows, dest interface{}, lenient bool, capacity int) error {
+	capacity = max(capacity, 0)
+	resultMap := ordered_map.New[interface{}, reflect.Value](ordered_map.WithCapacity[interface{}, reflect.Value](capacity))
+	defer rows.Close()
+	destinationPtrValue := reflect.ValueOf(dest)
+	if dest == nil {
+		return errors.New("dest cannot be nil")
+	}
+
+	if destinationPtrValue.Kind() != reflect.Ptr {
+		return errors.New("dest must be a pointer")
+	}
+
+	destinationValue := destinationPtrValue.Elem()
+
+	// de-reference pointer
+	destinationType := reflectutils.DeReferencePointer(destinationValue.Type())
+
+	if destinationType.Kind() != reflect.Slice {
+		return errors.New("dest must be a slice")
+	}
+
+	elType := destinationType.Elem()
+	// slice of pointers ([]*T) holds the mapped entities itself instead of their copies
+	isPointerSlice := elType.Kind() == reflect.Ptr
+	entityType := reflectutils.DeReferencePointer(elType)
+	if elType == reflect.TypeOf(map[string]any{}) {
+		// ad-hoc queries are collected as row maps without entity analysis
+		maps, err := ScanManyMaps(rows)
+		if err != nil {
+			return err
+		}
+		destinationValue.Set(reflect.ValueOf(maps).Convert(destinationType))
+		return nil
+	}
+	if IsScalarType(entityType) {
+		return scanScalarSlice(ctx, rows, destinationValue, destinationType)
+	}
+
+	state := newScanState()
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		return err
+	}
+	if entityMappingInfo.KeyField == nil {
+		// rows are merged into entities by primary key
+		return errors.New(fmt.Sprintf("slice element type %s has no primaryKey tag", entityType))
+	}
+	var rowErrors []error
+	// instance is allocated only when the previous one was used, as rows of already mapped entities do not need it
+	var newInstance reflect.Value
+	for rowIndex := 0; rows.Next(); rowIndex++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !newInstance.IsValid() {
+			newInstance = reflect.New(entityType)
+		}
+		rowInMap, err := rowToMap(rows)
+		if err != nil {
+			return err
+		}
+		if skipsRow(entityMappingInfo, rowInMap) {
+			continue
+		}
+
+		obj, err := mapToStruct(entityType, rowInMap, state, newInstance.Interface())
+		if err != nil || obj.Pointer() == newInstance.Pointer() {
+			newInstance = reflect.Value{}
+		}
+		if err != nil && lenient {
+			rowErrors = append(rowErrors, fmt.Errorf("row %d: %w", rowIndex, err))
+			continue
+		} else if err != nil {
+			return err
+		}
+		if obj.IsValid() {
+			keyValue, _ := entityMappingInfo.KeyField.keyValue(rowInMap)
+			if isPointerSlice {
+				resultMap.Set(keyValue, obj)
+			} else {
+				resultMap.Set(keyValue, obj.Elem())
+			}
+		}
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	result := reflect.MakeSlice(destinationType, resultMap.Len(), max(resultMap.Len(), capacity))
+	index := 0
+	for pair := resultMap.Oldest(); pair != nil; pair = pair.Next() {
+		result.Index(index).Set(pair.Value)
+		index++
+	}
+
+	destinationValue.Set(result)
+	return stderrors.Join(rowErrors...)
+}
 
-	lookupEntity := make(map[reflect.Type]map[interface{}]reflect.Value)
+// IsScalarType reports whether values of the type are scanned from a single column instead of mapped as entities.
+// Besides non-struct types these are time.Time, url.URL and structs implementing sql.Scanner, e.g. sql.NullInt64 or
+// pgtype.Text.
+func IsScalarType(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) || t == reflect.TypeOf(url.URL{}) ||
+		reflect.PointerTo(t).Implements(reflect.TypeOf((*sql.Scanner)(nil)).Elem())
+}
 
+// scanScalarSlice scans the only column of each row into slice of primitive values like []int or []string. NULL
+// values are appended as zero values.
+func scanScalarSlice(ctx context.Context, rows pgx.Rows, destinationValue reflect.Value, destinationType reflect.Type) error {
+	if columns := len(rows.FieldDescriptions()); columns != 1 {
+		return errors.New(fmt.Sprintf("scanning into %s requires exactly one column, got %d", destinationType, columns))
+	}
+
+	result := reflect.MakeSlice(destinationType, 0, 0)
 	for rows.Next() {
-		rowInMap, err := pgx.RowToMap(rows)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		element := reflect.New(destinationType.Elem()).Elem()
+		if values[0] != nil {
+			if err := setFieldValue(element, values[0]); err != nil {
+				return err
+			}
+		}
+		result = reflect.Append(result, element)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	destinationValue.Set(result)
+	return nil
+}
+
+// ScanEach maps each row into a new entity of entityType and passes pointer to it into fn, without holding the
+// result set in memory. Rows are not merged by primary key, so ScanEach is for flat rows only: one-to-many
+// relationships spanning multiple rows are not assembled. Scanning stops at the first error returned by fn.
+func ScanEach(rows pgx.Rows, entityType reflect.Type, fn func(v any) error) error {
+	defer rows.Close()
+	if entityType == nil {
+		return errors.New("entity type cannot be nil")
+	}
+	entityType = reflectutils.DeReferencePointer(entityType)
+	if entityType.Kind() != reflect.Struct {
+		return errors.New(fmt.Sprintf("entity(%s) must be a struct", entityType))
+	}
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		return err
+	}
+
+	// columns are the same for all rows of the result, so they are checked once instead of on every mapped row
+	state := newScanState()
+	columns := make(map[string]any, len(rows.FieldDescriptions()))
+	for _, fieldDescription := range rows.FieldDescriptions() {
+		columns[fieldDescription.Name] = nil
+	}
+	if StrictColumns {
+		if err := checkUnmappedColumns(entityType, entityMappingInfo, columns); err != nil {
+			return err
+		}
+		state.columnsChecked = true
+	}
+	if RequireAllFields {
+		if err := checkMissingColumns(entityType, entityMappingInfo, columns); err != nil {
+			return err
+		}
+		state.missingColumnsChecked[missingColumnsKey{entityType: entityType}] = struct{}{}
+	}
+
+	for rows.Next() {
+		rowInMap, err := rowToMap(rows)
 		if err != nil {
 			return err
 		}
-		_, err = mapToStruct(destinationType, rowInMap, lookupEntity, dest)
+		if skipsRow(entityMappingInfo, rowInMap) {
+			continue
+		}
+		// rows are not merged, so entities mapped from previous rows are forgotten
+		clear(state.lookup)
+		clear(state.children)
+		obj, err := mapToStruct(entityType, rowInMap, state, reflect.New(entityType).Interface())
 		if err != nil {
 			return err
 		}
+		if err := fn(obj.Interface()); err != nil {
+			return err
+		}
 	}
+	return rows.Err()
+}
 
-	if reflect.ValueOf(dest).Elem().IsZero() {
+// ScanManyMaps scans rows into maps keyed by column name. Values have the Go types pgx decodes them into and NULL
+// columns are nil values in the map.
+func ScanManyMaps(rows pgx.Rows) ([]map[string]any, error) {
+	defer rows.Close()
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		rowInMap, err := pgx.RowToMap(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rowInMap)
+	}
+	return result, rows.Err()
+}
+
+// ScanScalar scans the only column of the only row into dest, which is a pointer to a primitive value like *int,
+// *string or *time.Time, or to sql.Scanner like *sql.NullInt64. NULL leaves dest unchanged, except for sql.Scanner,
+// which scans it. Returns ErrNoRows when there are no rows and too many rows error when there are multiple.
+func ScanScalar(rows pgx.Rows, dest interface{}) error {
+	defer rows.Close()
+	destinationValue := reflect.ValueOf(dest)
+	if dest == nil || destinationValue.Kind() != reflect.Ptr || destinationValue.IsNil() {
+		return errors.New("dest must be a non-nil pointer")
+	}
+	if columns := len(rows.FieldDescriptions()); columns != 1 {
+		return errors.New(fmt.Sprintf("scalar query must return exactly one column, got %d", columns))
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
 		return ErrNoRows
 	}
+	values, err := rows.Values()
+	if err != nil {
+		return err
+	}
+	if values[0] != nil {
+		if err := setFieldValue(destinationValue.Elem(), values[0]); err != nil {
+			return err
+		}
+	} else if scanner, ok := sqlScanner(destinationValue.Elem()); ok {
+		if err := scanner.Scan(nil); err != nil {
+			return err
+		}
+	}
+	if rows.Next() {
+		return getTooManyRowsError(destinationValue.Elem().Type())
+	}
+	return rows.Err()
+}
+
+// scanState holds the entities mapped during one scan, so that rows belonging to the same entity are merged
+type scanState struct {
+	lookup   map[reflect.Type]map[interface{}]reflect.Value // mapped entities by type and primary key
+	children map[relationshipKey]map[interface{}]int        // positions of children in parent's relationship by primary key
+	// columnsChecked is set once the columns of the result were checked for StrictColumns. All rows of the result
+	// have the same columns, so they are checked only on the first row.
+	columnsChecked bool
+	// missingColumnsChecked holds entities which were checked for RequireAllFields like columnsChecked
+	missingColumnsChecked map[missingColumnsKey]struct{}
+}
+
+// missingColumnsKey identifies entity checked for RequireAllFields by its type and the relationship field it is mapped
+// into, as relationships may read the same entity type from differently prefixed columns
+type missingColumnsKey struct {
+	entityType reflect.Type
+	parentType reflect.Type
+	fieldIndex int
+}
+
+// relationshipKey identifies relationship field of one parent entity
+type relationshipKey struct {
+	parentType reflect.Type
+	parentKey  interface{}
+	fieldIndex int
+}
+
+func newScanState() *scanState {
+	return &scanState{
+		lookup:                make(map[reflect.Type]map[interface{}]reflect.Value),
+		children:              make(map[relationshipKey]map[interface{}]int),
+		missingColumnsChecked: make(map[missingColumnsKey]struct{}),
+	}
+}
+
+// checkPrimaryKey returns error when the entity has no primary key, as mapped entities are identified by it
+func checkPrimaryKey(entityType reflect.Type, entityMappingInfo *MappingInfo) error {
+	if entityMappingInfo.KeyField == nil {
+		return errors.New(fmt.Sprintf("entity(%s) has no primaryKey tag", entityType))
+	}
+	return nil
+}
+
+// hasNonNullColumn tells if any column of the entity has non-NULL value in the row
+func hasNonNullColumn(entityMappingInfo *MappingInfo, values map[string]any) bool {
+	for columnName := range entityMappingInfo.FieldMapping {
+		if value, selected := values[columnName]; selected && value != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// skipsRow reports whether the row is skipped by SkipNullKeys, because primary key of the top-level entity is NULL
+func skipsRow(entityMappingInfo *MappingInfo, values map[string]any) bool {
+	return SkipNullKeys && entityMappingInfo.KeyField != nil && entityMappingInfo.KeyField.isNull(values)
+}
+
+// Function to map database values to struct fields Returns object, if it is already mapper and error
+func mapToStruct(entityType reflect.Type, values map[string]any, state *scanState, dest interface{}) (reflect.Value, error) {
+	return mapEntity(entityType, values, state, dest, nil)
+}
+
+// entityPath identifies entity mapped into relationship of the parent by its primary key and the path of its
+// parents, so entities with the same key under different parents, e.g. items numbered per order, are kept apart
+type entityPath struct {
+	parent relationshipKey
+	key    interface{}
+}
+
+// mapEntity maps values into the entity like mapToStruct. Entities mapped into relationship of parent are looked up by
+// their path, top-level entities with nil parent by their primary key.
+func mapEntity(entityType reflect.Type, values map[string]any, state *scanState, dest interface{}, parent *relationshipKey) (reflect.Value, error) {
+
+	entityLookup, entityLookupExists := state.lookup[entityType]
+	if !entityLookupExists {
+		state.lookup[entityType] = make(map[interface{}]reflect.Value)
+		entityLookup = state.lookup[entityType]
+	}
+
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if StrictColumns && !state.columnsChecked {
+		// the first mapped entity is the root of the entity graph, so all columns of the result are in its values
+		state.columnsChecked = true
+		if err := checkUnmappedColumns(entityType, entityMappingInfo, values); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	if err := checkPrimaryKey(entityType, entityMappingInfo); err != nil {
+		return reflect.Value{}, err
+	}
+	keyValue, keyValueExists := entityMappingInfo.KeyField.keyValue(values)
+	if !keyValueExists {
+		return reflect.Value{}, errors.New("no key field found in values")
+	}
+
+	var lookupKey interface{} = keyValue
+	if parent != nil {
+		lookupKey = entityPath{parent: *parent, key: keyValue}
+	}
+
+	obj, entityExists := entityLookup[lookupKey]
+	if !entityExists {
+		if err := state.checkMissingColumns(entityType, entityMappingInfo, values, parent); err != nil {
+			return reflect.Value{}, err
+		}
+		// reflect_utils entity
+		obj = reflect.ValueOf(dest) // obj is now a reflect_utils.Value pointing to a pointer to the struct
+		if err := setEntityColumns(entityMappingInfo, obj.Elem(), values); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	err = mapRelationships(relationshipKey{parentType: entityType, parentKey: lookupKey}, entityMappingInfo, values, state, obj.Elem())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	state.lookup[entityType][lookupKey] = obj
+	return obj, nil
+}
+
+// setEntityColumns sets fields of the entity from the column values, including extra and JSON relationship fields
+func setEntityColumns(entityMappingInfo *MappingInfo, objValue reflect.Value, values map[string]any) error {
+	for columnName, structIndex := range entityMappingInfo.FieldMapping {
+
+		dbValue := values[columnName]
+		_, isJSONNull := dbValue.(jsonNull)
+		if isJSONNull && !entityMappingInfo.ColumnOptions[columnName].jsonb {
+			dbValue = nil // JSON null is NULL for fields not tagged as jsonb
+		}
+
+		if dbValue == nil {
+			field := fieldByIndex(objValue, structIndex, false)
+			if !field.IsValid() {
+				continue // field of nil embedded struct pointer, which stays nil until a column has value
+			}
+			if StrictNulls && !isNullableType(field.Type()) {
+				return fmt.Errorf("column %s is NULL, but %s field cannot hold NULL", columnName, field.Type())
+			}
+			if scanner, ok := sqlScanner(field); ok {
+				if err := scanner.Scan(nil); err != nil {
+					return fmt.Errorf("failed to map column %s: %w", columnName, err)
+				}
+			}
+			continue // Handle NULL values
+		}
+		if !isJSONNull {
+			dbValue = transformColumnValue(columnName, dbValue)
+		}
+
+		// Convert & Set Value
+		field := fieldByIndex(objValue, structIndex, true)
+		if err := setColumnValue(field, dbValue, entityMappingInfo.ColumnOptions[columnName]); err != nil {
+			return fmt.Errorf("failed to map column %s: %w", columnName, err)
+		}
+	}
+
+	if entityMappingInfo.ExtraField != nil {
+		if err := setExtraColumns(objValue.Field(*entityMappingInfo.ExtraField), entityMappingInfo, values); err != nil {
+			return err
+		}
+	}
+	return setJSONRelationships(entityMappingInfo, objValue, values)
+}
+
+// setExtraColumns collects columns which are not mapped anywhere in the entity graph into the extra map field
+func setExtraColumns(field reflect.Value, entityMappingInfo *MappingInfo, values map[string]any) error {
+	mappedColumns := graphColumns(entityMappingInfo)
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	for columnName, dbValue := range values {
+		if _, mapped := mappedColumns[columnName]; mapped {
+			continue
+		}
+
+		elem := reflect.New(field.Type().Elem()).Elem()
+		if _, isJSONNull := dbValue.(jsonNull); dbValue != nil && !isJSONNull {
+			if err := setFieldValue(elem, dbValue); err != nil {
+				return fmt.Errorf("failed to map extra column %s: %w", columnName, err)
+			}
+		}
+		field.SetMapIndex(reflect.ValueOf(columnName).Convert(field.Type().Key()), elem)
+	}
+	return nil
+}
+
+// checkMissingColumns checks the entity for RequireAllFields once per relationship it is mapped into
+func (s *scanState) checkMissingColumns(entityType reflect.Type, entityMappingInfo *MappingInfo, values map[string]any, parent *relationshipKey) error {
+	if !RequireAllFields {
+		return nil
+	}
+	key := missingColumnsKey{entityType: entityType}
+	if parent != nil {
+		key.parentType, key.fieldIndex = parent.parentType, parent.fieldIndex
+	}
+	if _, checked := s.missingColumnsChecked[key]; checked {
+		return nil
+	}
+	s.missingColumnsChecked[key] = struct{}{}
+	return checkMissingColumns(entityType, entityMappingInfo, values)
+}
+
+// checkMissingColumns returns error listing the mapped columns of the entity which are not selected in values
+func checkMissingColumns(entityType reflect.Type, entityMappingInfo *MappingInfo, values map[string]any) error {
+	var missingColumns []string
+	for columnName := range entityMappingInfo.FieldMapping {
+		if _, selected := values[columnName]; !selected {
+			missingColumns = append(missingColumns, columnName)
+		}
+	}
+	if len(missingColumns) > 0 {
+		sort.Strings(missingColumns)
+		return errors.New(fmt.Sprintf("columns %s of entity(%s) are missing from the result", strings.Join(missingColumns, ", "), entityType))
+	}
+	return nil
+}
+
+// checkUnmappedColumns returns error listing the columns which are not mapped anywhere in the entity graph. Entities
+// with extra field receive all unmapped columns, so they have no unmapped columns.
+func checkUnmappedColumns(entityType reflect.Type, entityMappingInfo *MappingInfo, values map[string]any) error {
+	if entityMappingInfo.ExtraField != nil {
+		return nil
+	}
+	mappedColumns := graphColumns(entityMappingInfo)
+	var unmappedColumns []string
+	for columnName := range values {
+		if _, mapped := mappedColumns[columnName]; !mapped {
+			unmappedColumns = append(unmappedColumns, columnName)
+		}
+	}
+	if len(unmappedColumns) > 0 {
+		sort.Strings(unmappedColumns)
+		return errors.New(fmt.Sprintf("columns %s are not mapped to any field of entity(%s)", strings.Join(unmappedColumns, ", "), entityType))
+	}
+	return nil
+}
+
+// graphColumns returns columns mapped anywhere in the entity graph. They are collected when the graph is analyzed, so
+// they are collected here only for mapping info set with SetEntityGraphMappingInfo.
+func graphColumns(entityMappingInfo *MappingInfo) map[string]struct{} {
+	if entityMappingInfo.GraphColumns != nil {
+		return entityMappingInfo.GraphColumns
+	}
+	columns := make(map[string]struct{})
+	collectGraphColumns(entityMappingInfo, "", columns, make(map[*MappingInfo]struct{}))
+	return columns
+}
+
+// collectGraphColumns collects columns mapped by the entity and all of its relationships, prefixed with the column
+// prefixes of the relationships
+func collectGraphColumns(entityMappingInfo *MappingInfo, prefix string, columns map[string]struct{}, visited map[*MappingInfo]struct{}) {
+	if _, seen := visited[entityMappingInfo]; seen {
+		return
+	}
+	visited[entityMappingInfo] = struct{}{}
+	defer delete(visited, entityMappingInfo)
+
+	for columnName := range entityMappingInfo.FieldMapping {
+		columns[prefix+columnName] = struct{}{}
+	}
+	for _, columnName := range entityMappingInfo.JSONRelationships {
+		columns[prefix+columnName] = struct{}{}
+	}
+	for fieldIndex, relationshipType := range entityMappingInfo.Relationships {
+		relationshipType = reflectutils.DeReferencePointer(relationshipType)
+		if relationshipType.Kind() == reflect.Slice {
+			relationshipType = reflectutils.DeReferencePointer(relationshipType.Elem())
+		}
+		if relationshipMappingInfo, exists := GetEntityGraphMappingInfo(relationshipType); exists && relationshipMappingInfo != nil {
+			relationshipPrefix := prefix + entityMappingInfo.RelationshipPrefixes[fieldIndex]
+			collectGraphColumns(relationshipMappingInfo, relationshipPrefix, columns, visited)
+		}
+	}
+}
+
+// withoutPrefix returns values of the columns having the prefix, keyed by column names without the prefix
+func withoutPrefix(values map[string]any, prefix string) map[string]any {
+	prefixedValues := make(map[string]any)
+	for columnName, value := range values {
+		if strings.HasPrefix(columnName, prefix) {
+			prefixedValues[strings.TrimPrefix(columnName, prefix)] = value
+		}
+	}
+	return prefixedValues
+}
+
+// logic to handle entity relationships. This function creates struct and then appends to current struct.
+// Slice relationships (oneToMany and manyToMany) add each child only once per parent, keyed by child's primary key,
+// so joining multiple collections of the parent, which returns their cartesian product, maps each child once.
+// Children keep the order they were first seen in, also when rows of the parent are interleaved with other parents.
+// Relationships with prefix tag are mapped from the columns having the prefix, e.g. owner_name for name column.
+// For manyToMany the query is expected to join parent, join table and child, returning one row per parent-child pair:
+//
+//	SELECT u.user_id, u.user_name, r.role_id, r.role_name
+//	FROM users u
+//	LEFT JOIN user_roles ur ON ur.user_id = u.user_id
+//	LEFT JOIN roles r ON r.role_id = ur.role_id
+func mapRelationships(parent relationshipKey, entityMappingInfo *MappingInfo, values map[string]any, state *scanState, obj reflect.Value) error {
+	for fieldIndex, relationshipEntityType := range entityMappingInfo.Relationships {
+		relationshipEntityType := reflectutils.DeReferencePointer(relationshipEntityType)
+
+		isSlice := relationshipEntityType.Kind() == reflect.Slice
+		if isSlice {
+			relationshipEntityType = reflectutils.DeReferencePointer(relationshipEntityType.Elem())
+		}
+
+		prefix, hasPrefix := entityMappingInfo.RelationshipPrefixes[fieldIndex]
+		if isSlice && !hasPrefix && reflectutils.DeReferencePointer(relationshipEntityType) == parent.parentType {
+			// row cannot be its own child, trees of self-referential entities are assembled by ScanTree. Prefixed
+			// self-references, e.g. manager of an employee, are read from their own columns.
+			continue
+		}
+
+		relationshipValues := values
+		if hasPrefix {
+			relationshipValues = withoutPrefix(values, prefix)
+		}
+
+		relationshipMappingInfo, err := getMappingInfo(relationshipEntityType)
+		if err != nil {
+			return err
+		}
+		if keyColumn, sharesKey := entityMappingInfo.RelationshipKeys[fieldIndex]; sharesKey && relationshipMappingInfo.KeyField != nil {
+			// without any non-NULL column the extension row does not exist, e.g. on LEFT JOIN
+			if keyValue, selected := values[keyColumn]; selected && hasNonNullColumn(relationshipMappingInfo, relationshipValues) {
+				relationshipValues = maps.Clone(relationshipValues)
+				relationshipValues[relationshipMappingInfo.KeyField.dbPrimaryKeyName] = keyValue
+			}
+		}
+		if relationshipMappingInfo.KeyField != nil {
+			if _, selected := relationshipMappingInfo.KeyField.keyValue(relationshipValues); !selected {
+				continue // relationship is not selected by the query
+			}
+			if relationshipMappingInfo.KeyField.isNull(relationshipValues) {
+				continue // NULL key means there is no child in this row
+			}
+		}
+
+		parent.fieldIndex = fieldIndex
+		value, err := mapEntity(relationshipEntityType, relationshipValues, state, reflect.New(relationshipEntityType).Interface(), &parent)
+		if err != nil {
+			return err
+		}
+		if value.IsValid() && reflectutils.IsStructPointerWithNonZeroFields(value) {
+			field := obj.Field(fieldIndex)
+			if isSlice {
+				position, added := state.addChild(parent, relationshipMappingInfo, relationshipValues, childCount(field))
+				if !added {
+					refreshChild(field, position, value)
+				} else {
+					appendChild(field, value)
+				}
+				continue
+			} else if _, added := state.addChild(parent, relationshipMappingInfo, relationshipValues, 0); added &&
+				reflectutils.IsStruct(field) && !reflect.Indirect(field).IsZero() {
+				// the same child repeated in rows of the parent, e.g. next to its oneToMany siblings, is set again, so
+				// relationships of the child mapped from this row are not lost. Another child is one too many.
+				return getTooManyRowsError(relationshipEntityType)
+			}
+			err = setFieldValue(field, value.Interface())
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-// ScanMany scans rows into a slice of objects.
-func ScanMany(rows pgx.Rows, dest interface{}) error {
-	resultMap := ordered_map.New[interface{}, reflect.Value]()
-	defer rows.Close()
-	destinationPtrValue := reflect.ValueOf(dest)
-	if dest == nil {
-		return errors.New("dest cannot be nil")
+// childCount returns length of the relationship slice, which is either a slice or a pointer to a slice
+func childCount(field reflect.Value) int {
+	if field.Kind() == reflect.Ptr && field.IsNil() {
+		return 0
 	}
+	return reflect.Indirect(field).Len()
+}
 
-	if destinationPtrValue.Kind() != reflect.Ptr {
-		return errors.New("dest must be a pointer")
+// addChild registers child in the parent's relationship at the given position. When the child was already added,
+// returns its position and false.
+func (s *scanState) addChild(parent relationshipKey, childMappingInfo *MappingInfo, values map[string]any, position int) (int, bool) {
+	childKey, _ := childMappingInfo.KeyField.keyValue(values)
+
+	children, exists := s.children[parent]
+	if !exists {
+		children = make(map[interface{}]int)
+		s.children[parent] = children
+	}
+	if addedPosition, added := children[childKey]; added {
+		return addedPosition, false
 	}
+	children[childKey] = position
+	return position, true
+}
 
-	destinationValue := destinationPtrValue.Elem()
+// appendChild appends child into the relationship slice, which is either a slice or a pointer to a slice. Slices of
+// pointers hold the child itself, other slices its copy.
+func appendChild(field reflect.Value, child reflect.Value) {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	if field.Type().Elem().Kind() == reflect.Ptr {
+		field.Set(reflect.Append(field, child))
+	} else {
+		field.Set(reflect.Append(field, child.Elem()))
+	}
+}
 
-	// de-reference pointer
-	destinationType := reflectutils.DeReferencePointer(destinationValue.Type())
+// refreshChild replaces the copy of child in slice of values with the child, so relationships of the child mapped
+// from later rows are not lost. Slices of pointers share the child, so there is nothing to refresh.
+func refreshChild(field reflect.Value, position int, child reflect.Value) {
+	element := reflect.Indirect(field).Index(position)
+	if element.Kind() != reflect.Ptr {
+		element.Set(child.Elem())
+	}
+}
 
-	if destinationType.Kind() != reflect.Slice {
-		return errors.New("dest must be a slice")
+// setColumnValue sets column value into the field, honoring the options given in the db tag
+func setColumnValue(field reflect.Value, value interface{}, options ColumnOptions) error {
+	if options.jsonb {
+		return setJSONField(field, value)
+	}
+	if options.join {
+		return setJoinedField(field, value)
+	}
+	if options.composite {
+		return setCompositeField(field, value)
+	}
+	if options.format == "interval" {
+		return setIntervalField(field, value)
 	}
+	if options.format != "" {
+		return setFormattedTimeField(field, value, options.format)
+	}
+	return setFieldValue(field, value)
+}
 
-	elType := destinationType.Elem()
+// setIntervalField sets interval into time.Duration field tagged with `format:"interval"`. Integer values are
+// microseconds, like interval is stored, instead of nanoseconds of time.Duration.
+func setIntervalField(field reflect.Value, value interface{}) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setIntervalField(field.Elem(), value)
+	}
+	if field.Type() != reflect.TypeOf(time.Duration(0)) {
+		return fmt.Errorf("format interval is supported only for time.Duration fields, got %s", field.Type())
+	}
 
-	lookupEntity := make(map[reflect.Type]map[interface{}]reflect.Value)
-	result := reflect.MakeSlice(destinationType, 0, 0)
-	for rows.Next() {
-		newInstance := reflect.New(elType).Interface()
-		rowInMap, err := pgx.RowToMap(rows)
-		if err != nil {
-			return err
+	v := reflect.ValueOf(value)
+	switch {
+	case v.CanInt():
+		field.SetInt(int64(time.Duration(v.Int()) * time.Microsecond))
+	case v.CanUint():
+		field.SetInt(int64(time.Duration(v.Uint()) * time.Microsecond))
+	default:
+		return setFieldValue(field, value)
+	}
+	return nil
+}
+
+// setFormattedTimeField sets value stored in the format of `format` tag into time.Time field. Formats unix and
+// unixmilli convert integer epoch seconds and milliseconds, other formats are layouts parsing text, e.g.
+// `format:"2006-01-02 15:04"`.
+func setFormattedTimeField(field reflect.Value, value interface{}, format string) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
 		}
+		return setFormattedTimeField(field.Elem(), value, format)
+	}
+	if field.Type() != reflect.TypeOf(time.Time{}) {
+		return fmt.Errorf("format %s is supported only for time.Time fields, got %s", format, field.Type())
+	}
 
-		obj, err := mapToStruct(elType, rowInMap, lookupEntity, newInstance)
+	var t time.Time
+	switch format {
+	case "unix", "unixmilli":
+		v := reflect.ValueOf(value)
+		var epoch int64
+		switch v.Kind() {
+		case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+			epoch = v.Int()
+		case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
+			epoch = int64(v.Uint())
+		default:
+			return fmt.Errorf("type mismatch: expected integer %s time, got %T", format, value)
+		}
+		if format == "unix" {
+			t = time.Unix(epoch, 0).UTC()
+		} else {
+			t = time.UnixMilli(epoch).UTC()
+		}
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("type mismatch: expected text time, got %T", value)
+		}
+		parsed, err := time.Parse(format, text)
 		if err != nil {
 			return err
 		}
-		if obj.IsValid() {
-			if obj.Kind() == reflect.Ptr {
-				obj = obj.Elem()
-			}
-
-			entityMappingInfo, _ := GetEntityGraphMappingInfo(elType)
-			keyField := entityMappingInfo.KeyField.structPrimaryKeyFieldName
+		t = parsed
+	}
+	field.Set(reflect.ValueOf(inTimeLocation(t)))
+	return nil
+}
 
-			field := obj.FieldByName(keyField)
-			actualValue := field.Interface()
-			resultMap.Set(actualValue, obj)
+// setCompositeField maps composite value into struct field, or array of composites into slice of structs field.
+// pgx decodes registered composite types into maps keyed by attribute name, which are mapped like columns.
+func setCompositeField(field reflect.Value, value interface{}) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
 		}
+		return setCompositeField(field.Elem(), value)
 	}
-
-	for pair := resultMap.Oldest(); pair != nil; pair = pair.Next() {
-		result = reflect.Append(result, pair.Value)
+	if field.Kind() == reflect.Struct {
+		attributes, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("type mismatch: expected composite, got %T", value)
+		}
+		return setCompositeAttributes(field, attributes)
 	}
 
-	destinationValue.Set(result)
+	v := reflect.ValueOf(value)
+	if field.Kind() != reflect.Slice || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+		return fmt.Errorf("cannot map %T composite into %s field", value, field.Type())
+	}
+	elements := reflect.MakeSlice(field.Type(), v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		element := v.Index(i).Interface()
+		if element == nil {
+			continue // NULL composite leaves the element zero
+		}
+		if err := setCompositeField(elements.Index(i), element); err != nil {
+			return fmt.Errorf("composite %d: %w", i, err)
+		}
+	}
+	field.Set(elements)
 	return nil
 }
 
-// Function to map database values to struct fields Returns object, if it is already mapper and error
-func mapToStruct(entityType reflect.Type, values map[string]any, lookup map[reflect.Type]map[interface{}]reflect.Value,
-	dest interface{}) (reflect.Value, error) {
-
-	entityLookup, entityLookupExists := lookup[entityType]
-	if !entityLookupExists {
-		lookup[entityType] = make(map[interface{}]reflect.Value)
-		entityLookup = lookup[entityType]
+func setCompositeAttributes(field reflect.Value, attributes map[string]any) error {
+	compositeMappingInfo, err := getMappingInfo(field.Type())
+	if err != nil {
+		return err
 	}
-
-	entityMappingInfo, mappingInfoExists := GetEntityGraphMappingInfo(entityType)
-	if !mappingInfoExists {
-		analyzeEntityGraphs(entityType)
-		entityMappingInfo, _ = GetEntityGraphMappingInfo(entityType)
-		if entityMappingInfo == nil {
-			return reflect.Value{}, errors.New(fmt.Sprintf("no mapping info found for entity(%s)", entityType))
+	for attributeName, structIndex := range compositeMappingInfo.FieldMapping {
+		attributeValue := attributes[attributeName]
+		if attributeValue == nil {
+			continue
+		}
+		err := setColumnValue(fieldByIndex(field, structIndex, true), attributeValue, compositeMappingInfo.ColumnOptions[attributeName])
+		if err != nil {
+			return fmt.Errorf("failed to map attribute %s: %w", attributeName, err)
 		}
 	}
-	keyValue, keyValueExists := values[entityMappingInfo.KeyField.dbPrimaryKeyName]
-	if !keyValueExists {
-		return reflect.Value{}, errors.New("no key field found in values")
-	}
+	return nil
+}
 
-	obj, entityExists := entityLookup[keyValue]
-	if !entityExists {
-		// reflect_utils entity
-		obj = reflect.ValueOf(dest) // obj is now a reflect_utils.Value pointing to a pointer to the struct
-		objValue := obj.Elem()      // Dereference to get the actual struct
-		for columnName, structIndex := range entityMappingInfo.FieldMapping {
+// setJoinedField joins elements of array column with JoinSeparator into a string field
+func setJoinedField(field reflect.Value, value interface{}) error {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return setFieldValue(field, value)
+	}
+	if field.Kind() != reflect.String {
+		return errors.New(fmt.Sprintf("cannot join array into %s field", field.Type()))
+	}
 
-			field := objValue.Field(structIndex)
-			dbValue := values[columnName]
+	elements := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		element := v.Index(i)
+		for element.Kind() == reflect.Interface || element.Kind() == reflect.Ptr {
+			element = element.Elem()
+		}
+		if !element.IsValid() {
+			continue // NULL elements are skipped
+		}
+		if text, ok := textValue(element); ok {
+			elements = append(elements, text)
+		} else {
+			elements = append(elements, fmt.Sprint(element.Interface()))
+		}
+	}
+	field.SetString(strings.Join(elements, JoinSeparator))
+	return nil
+}
 
-			if dbValue == nil {
-				continue // Handle NULL values
-			}
+// jsonNull is the value of json and jsonb column holding JSON null, which pgx decodes into nil like SQL NULL
+type jsonNull struct{}
 
-			// Convert & Set Value
-			if err := setFieldValue(field, dbValue); err != nil {
-				return reflect.Value{}, fmt.Errorf("failed to map column %s: %w", columnName, err)
-			}
+// rowToMap reads the row like pgx.RowToMap, but keeps JSON null of json and jsonb columns apart from SQL NULL
+func rowToMap(rows pgx.Rows) (map[string]any, error) {
+	rowInMap, err := pgx.RowToMap(rows)
+	if err != nil {
+		return nil, err
+	}
+	rawValues := rows.RawValues()
+	for i, description := range rows.FieldDescriptions() {
+		if description.DataTypeOID != pgtype.JSONOID && description.DataTypeOID != pgtype.JSONBOID {
+			continue
+		}
+		if rowInMap[description.Name] == nil && i < len(rawValues) && isRawJSONNull(rawValues[i]) {
+			rowInMap[description.Name] = jsonNull{}
 		}
 	}
-	err := mapRelationships(entityMappingInfo, values, lookup, obj.Elem())
-	if err != nil {
-		return reflect.Value{}, err
+	return rowInMap, nil
+}
+
+// isRawJSONNull reports whether raw column value is JSON null. Binary jsonb is prefixed with version byte 1.
+func isRawJSONNull(raw []byte) bool {
+	if raw == nil {
+		return false
 	}
-	lookup[entityType][keyValue] = obj
-	return obj, nil
+	raw = bytes.TrimPrefix(raw, []byte{1})
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
 }
 
-// logic to handle entity relationships. This function creates struct and then appends to current struct
-func mapRelationships(entityMappingInfo *MappingInfo, values map[string]any, lookup map[reflect.Type]map[interface{}]reflect.Value, obj reflect.Value) error {
-	for fieldIndex, relationshipEntityType := range entityMappingInfo.Relationships {
-		relationshipEntityType := reflectutils.DeReferencePointer(relationshipEntityType)
+// setJSONField decodes JSON value into the field. pgx already decodes json and jsonb columns into maps and slices,
+// so these are encoded back before decoding them into the field type.
+func setJSONField(field reflect.Value, value interface{}) error {
+	if !field.CanSet() {
+		return errors.New("field is not settable")
+	}
 
-		if relationshipEntityType.Kind() == reflect.Slice {
-			relationshipEntityType = relationshipEntityType.Elem()
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case jsonNull:
+		data = []byte("null")
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %T as JSON: %w", value, err)
 		}
+		data = encoded
+	}
 
-		value, err := mapToStruct(relationshipEntityType, values, lookup, reflect.New(relationshipEntityType).Interface())
+	// JSON null clears the field, while SQL NULL never reaches here and leaves the field untouched
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
 
-		if err != nil {
-			return err
-		}
-		if value.IsValid() && reflectutils.IsStructPointerWithNonZeroFields(value) {
-			field := obj.Field(fieldIndex)
-			if reflectutils.IsStruct(field) && !reflect.Indirect(field).IsZero() {
-				return getTooManyRowsError(relationshipEntityType)
-			}
-			err = setFieldValue(field, value.Interface())
-			if err != nil {
-				return err
-			}
-		}
+	if err := json.Unmarshal(data, field.Addr().Interface()); err != nil {
+		return fmt.Errorf("failed to decode JSON into %s: %w", field.Type(), err)
 	}
 	return nil
 }
 
+// isDecodedJSON reports whether value is a JSON object or array decoded by pgx from json or jsonb column
+func isDecodedJSON(value any) bool {
+	switch value.(type) {
+	case map[string]any, []any:
+		return true
+	default:
+		return false
+	}
+}
+
+// looksLikeJSON reports whether value is a string or []byte holding a JSON object or array
+func looksLikeJSON(v reflect.Value) bool {
+	var data []byte
+	switch {
+	case v.Kind() == reflect.String:
+		data = []byte(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		data = v.Bytes()
+	default:
+		return false
+	}
+
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[') && json.Valid(data)
+}
+
 // Function to Convert Database Value to Go Struct Field
 func setFieldValue(field reflect.Value, value interface{}) error {
 
 	if !field.CanSet() {
 		return errors.New("field is not settable")
 	}
+	if converter, ok := getConverter(field.Type()); ok {
+		return converter(field, value)
+	}
 	// if field is not pointer, but value is pointer, then dereference
 	v := reflect.ValueOf(value)
 	if field.Kind() != reflect.Ptr && v.Kind() == reflect.Ptr && !v.IsNil() {
 		value = v.Elem().Interface()
 		v = v.Elem()
 	}
+	if unwrapped, ok := unwrapPgtype(value); ok && !v.Type().AssignableTo(field.Type()) {
+		if unwrapped == nil {
+			// invalid wrapper holds NULL, so the field is left unset
+			return nil
+		}
+		return setFieldValue(field, unwrapped)
+	}
+	if scanner, ok := sqlScanner(field); ok && !v.Type().AssignableTo(field.Type()) {
+		return scanner.Scan(value)
+	}
+	if isEnumType(field.Type()) {
+		return setEnumField(field, value, v)
+	}
 
 	switch field.Kind() {
 	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
@@ -286,13 +1539,89 @@ func setFieldValue(field reflect.Value, value interface{}) error {
 		return setStructField(field, value, v)
 	case reflect.Slice:
 		return setSliceField(field, value, v)
+	case reflect.Map:
+		return setMapField(field, value, v)
 	case reflect.Ptr:
 		return setPointerField(field, v)
+	case reflect.Interface:
+		return setInterfaceField(field, value, v)
 	default:
 		return fmt.Errorf("unsupported field type: %s", field.Kind().String())
 	}
 }
 
+// isEnumType tells if the type is named type with string or integer underlying kind, e.g. type Status string
+func isEnumType(t reflect.Type) bool {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8,
+		reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
+		return true
+	default:
+		return false
+	}
+}
+
+// setEnumField converts value of the same kind family into the named type, e.g. enum label into type Status string,
+// and falls back to the setter of the underlying kind for other values
+func setEnumField(field reflect.Value, value interface{}, v reflect.Value) error {
+	var err error
+	switch {
+	case field.Kind() == reflect.String && v.Kind() == reflect.String:
+		field.Set(v.Convert(field.Type()))
+	case field.CanInt() && v.CanInt():
+		if field.OverflowInt(v.Int()) {
+			return fmt.Errorf("value %d overflows %s field", v.Int(), field.Type())
+		}
+		field.Set(v.Convert(field.Type()))
+	case field.CanUint() && v.CanUint():
+		if field.OverflowUint(v.Uint()) {
+			return fmt.Errorf("value %d overflows %s field", v.Uint(), field.Type())
+		}
+		field.Set(v.Convert(field.Type()))
+	case field.Kind() == reflect.String:
+		err = setStringField(field, value, v)
+	case field.CanInt():
+		err = setIntField(field, value, v)
+	default:
+		err = setUintField(field, value, v)
+	}
+	if err != nil {
+		return fmt.Errorf("%w for %s field", err, field.Type())
+	}
+	return nil
+}
+
+// fieldByIndex returns the field of struct value by index path. Nil embedded struct pointers on the path are allocated
+// when allocate is set, otherwise invalid value is returned for fields behind them.
+func fieldByIndex(v reflect.Value, index []int, allocate bool) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !allocate {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fieldIndex)
+	}
+	return v
+}
+
+// sqlScanner returns the field as sql.Scanner, e.g. sql.NullString, when the field implements it
+func sqlScanner(field reflect.Value) (sql.Scanner, bool) {
+	if field.Kind() == reflect.Ptr || !field.CanAddr() {
+		return nil, false
+	}
+	scanner, ok := field.Addr().Interface().(sql.Scanner)
+	return scanner, ok
+}
+
 func setPointerField(field reflect.Value, v reflect.Value) error {
 	if field.IsNil() {
 		// Initialize the pointer if it is nil
@@ -302,15 +1631,67 @@ func setPointerField(field reflect.Value, v reflect.Value) error {
 	return setFieldValue(field.Elem(), v.Interface())
 }
 
+func setInterfaceField(field reflect.Value, value interface{}, v reflect.Value) error {
+	if !v.Type().AssignableTo(field.Type()) {
+		return fmt.Errorf("type mismatch: %T does not implement %s", value, field.Type())
+	}
+	field.Set(v)
+	return nil
+}
+
 func setStringField(field reflect.Value, value interface{}, v reflect.Value) error {
 	if v.Kind() == reflect.String {
 		field.SetString(v.String())
+	} else if text, ok := textValue(v); ok {
+		field.SetString(text)
+	} else if text, ok := numberText(v); ok && CoerceStringNumbers {
+		field.SetString(text)
 	} else {
 		return fmt.Errorf("type mismatch: expected string, got %T", value)
 	}
 	return nil
 }
 
+// numberText formats integer and float values as text
+func numberText(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float64, reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), true
+	default:
+		return "", false
+	}
+}
+
+// textValue extracts text from values which are not plain strings, e.g. enums registered in pgx type map. Value is
+// accepted when it implements pgtype.TextValuer or fmt.Stringer.
+func textValue(v reflect.Value) (string, bool) {
+	candidates := []reflect.Value{v}
+	if v.CanAddr() {
+		candidates = append(candidates, v.Addr())
+	}
+
+	for _, candidate := range candidates {
+		if !candidate.CanInterface() {
+			continue
+		}
+		switch source := candidate.Interface().(type) {
+		case pgtype.TextValuer:
+			text, err := source.TextValue()
+			if err != nil || !text.Valid {
+				return "", false
+			}
+			return text.String, true
+		case fmt.Stringer:
+			return source.String(), true
+		}
+	}
+	return "", false
+}
+
 func setBoolField(field reflect.Value, value interface{}, v reflect.Value) error {
 	if v.Kind() == reflect.Bool {
 		field.SetBool(v.Bool())
@@ -321,17 +1702,54 @@ func setBoolField(field reflect.Value, value interface{}, v reflect.Value) error
 }
 
 func setIntField(field reflect.Value, value interface{}, v reflect.Value) error {
+	if timeValue, ok := value.(pgtype.Time); ok && field.Type() == reflect.TypeOf(time.Duration(0)) {
+		field.SetInt(int64(timeOfDayFromMicroseconds(timeValue.Microseconds).SinceMidnight()))
+		return nil
+	}
+	if interval, ok := value.(pgtype.Interval); ok && field.Type() == reflect.TypeOf(time.Duration(0)) {
+		if !interval.Valid {
+			return nil // NULL interval leaves the field unset
+		}
+		field.SetInt(int64(intervalDuration(interval)))
+		return nil
+	}
 	switch v.Kind() {
 	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
 		field.SetInt(v.Int())
 	case reflect.Float64: // Allow conversion from float to int
+		if math.IsNaN(v.Float()) {
+			return fmt.Errorf("cannot assign NaN to %s field", field.Kind())
+		}
 		field.SetInt(int64(v.Float()))
 	default:
+		if numeric, ok := value.(pgtype.Numeric); ok {
+			return setIntFromNumeric(field, numeric)
+		}
+		if v.Kind() == reflect.String && CoerceStringNumbers {
+			intValue, err := strconv.ParseInt(v.String(), 10, 64)
+			if err != nil {
+				return fmt.Errorf("cannot coerce %q into %s field: %w", v.String(), field.Kind(), err)
+			}
+			field.SetInt(intValue)
+			return nil
+		}
 		return fmt.Errorf("type mismatch: expected int64, got %T", value)
 	}
 	return nil
 }
 
+func setIntFromNumeric(field reflect.Value, numeric pgtype.Numeric) error {
+	if numeric.NaN {
+		return fmt.Errorf("cannot assign numeric NaN to %s field", field.Kind())
+	}
+	intValue, err := numeric.Int64Value()
+	if err != nil {
+		return err
+	}
+	field.SetInt(intValue.Int64)
+	return nil
+}
+
 func setUintField(field reflect.Value, value interface{}, v reflect.Value) error {
 	if v.Kind() == reflect.Int || v.Kind() == reflect.Int64 || v.Kind() == reflect.Int32 || v.Kind() == reflect.Int16 || v.Kind() == reflect.Int8 {
 		intValue := v.Int()
@@ -339,10 +1757,16 @@ func setUintField(field reflect.Value, value interface{}, v reflect.Value) error
 			return fmt.Errorf("cannot assign negative value %d to uint field", intValue)
 		}
 		field.SetUint(uint64(intValue)) // Safely convert int64 to uint64
-	} else if v.Kind() == reflect.Uint || v.Kind() == reflect.Uint64 {
-		field.SetUint(v.Uint()) // Directly assign uint values
+	} else if v.Kind() == reflect.Uint || v.Kind() == reflect.Uint64 || v.Kind() == reflect.Uint32 || v.Kind() == reflect.Uint16 || v.Kind() == reflect.Uint8 {
+		field.SetUint(v.Uint()) // Directly assign uint values, e.g. uint32 of oid columns
+	} else if v.Kind() == reflect.String && CoerceStringNumbers {
+		uintValue, err := strconv.ParseUint(v.String(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot coerce %q into %s field: %w", v.String(), field.Kind(), err)
+		}
+		field.SetUint(uintValue)
 	} else {
-		return fmt.Errorf("type mismatch: expected uint or uint64, got %T", value)
+		return fmt.Errorf("type mismatch: expected int or uint, got %T", value)
 	}
 	return nil
 }
@@ -352,6 +1776,19 @@ func setFloatField(field reflect.Value, value interface{}, v reflect.Value) erro
 		field.SetFloat(v.Float())
 	} else if v.Kind() == reflect.Int || v.Kind() == reflect.Int64 {
 		field.SetFloat(float64(v.Int())) // Allow int -> float
+	} else if numeric, ok := value.(pgtype.Numeric); ok {
+		// numeric NaN and infinity are converted into math.NaN() and math.Inf()
+		floatValue, err := numeric.Float64Value()
+		if err != nil {
+			return err
+		}
+		field.SetFloat(floatValue.Float64)
+	} else if v.Kind() == reflect.String && CoerceStringNumbers {
+		floatValue, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return fmt.Errorf("cannot coerce %q into %s field: %w", v.String(), field.Kind(), err)
+		}
+		field.SetFloat(floatValue)
 	} else {
 		return fmt.Errorf("type mismatch: expected float64, got %T", value)
 	}
@@ -360,18 +1797,158 @@ func setFloatField(field reflect.Value, value interface{}, v reflect.Value) erro
 func setStructField(field reflect.Value, value interface{}, v reflect.Value) error {
 	if field.Type() == reflect.TypeOf(time.Time{}) {
 		if v.Type() == reflect.TypeOf(time.Time{}) {
-			field.Set(v)
+			field.Set(reflect.ValueOf(inTimeLocation(v.Interface().(time.Time))))
+		} else if v.Kind() == reflect.String {
+			// text timestamps, e.g. of to_char expressions or JSON, are expected in RFC 3339 format
+			t, err := time.Parse(time.RFC3339, v.String())
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(inTimeLocation(t)))
 		} else {
 			return fmt.Errorf("type mismatch: expected time.Time, got %T", value)
 		}
+	} else if field.Type() == reflect.TypeOf(url.URL{}) {
+		return setURLField(field, value, v)
+	} else if wrapper, ok := getGenericWrapper(field.Type()); ok {
+		return setGenericWrapperField(field, wrapper, value)
+	} else if field.Type() == reflect.TypeOf(TimeOfDay{}) {
+		return setTimeOfDayField(field, value)
+	} else if source, ok := value.(pgtype.Range[any]); ok && isRangeType(field.Type()) {
+		return setRangeField(field, source)
+	} else if looksLikeJSON(v) {
+		return setJSONField(field, value)
+	} else if isDecodedJSON(value) {
+		// pgx decodes json and jsonb columns into maps and slices
+		return setJSONField(field, value)
+	} else if v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+	} else if (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && !v.IsNil() && v.Elem().Type().AssignableTo(field.Type()) {
+		field.Set(v.Elem())
 	} else {
-		// Ensure assignability for other structs
-		if v.Type().AssignableTo(field.Type()) || v.Elem().Type().AssignableTo(field.Type()) {
-			field.Set(v)
-		} else {
-			return fmt.Errorf("type mismatch: expected %s, got %T", field.Type().Name(), v)
+		return fmt.Errorf("type mismatch: expected %s, got %T", field.Type(), value)
+	}
+	return nil
+}
+
+// setTimeOfDayField sets time or timetz value into TimeOfDay field
+func setTimeOfDayField(field reflect.Value, value interface{}) error {
+	switch source := value.(type) {
+	case pgtype.Time:
+		field.Set(reflect.ValueOf(timeOfDayFromMicroseconds(source.Microseconds)))
+	case string:
+		timeOfDay, err := parseTimeOfDay(source)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(timeOfDay))
+	default:
+		return fmt.Errorf("type mismatch: expected time of day, got %T", value)
+	}
+	return nil
+}
+
+// inTimeLocation converts non-zero time into the configured TimeLocation
+func inTimeLocation(t time.Time) time.Time {
+	if TimeLocation == nil || t.IsZero() {
+		return t
+	}
+	return t.In(TimeLocation)
+}
+
+// setRangeField sets range decoded by pgx into Range or pgtype.Range field, converting the bounds into its type
+func setRangeField(field reflect.Value, source pgtype.Range[any]) error {
+	bounds := map[string]any{"Lower": source.Lower, "Upper": source.Upper}
+	for name, bound := range bounds {
+		boundField := field.FieldByName(name)
+		boundField.Set(reflect.Zero(boundField.Type()))
+		if bound == nil {
+			continue // unbounded or empty range
+		}
+		if err := setFieldValue(boundField, bound); err != nil {
+			return fmt.Errorf("failed to map %s bound of range: %w", strings.ToLower(name), err)
 		}
 	}
+	field.FieldByName("LowerType").Set(reflect.ValueOf(source.LowerType))
+	field.FieldByName("UpperType").Set(reflect.ValueOf(source.UpperType))
+	field.FieldByName("Valid").SetBool(source.Valid)
+	return nil
+}
+
+func setURLField(field reflect.Value, value interface{}, v reflect.Value) error {
+	switch {
+	case v.Type() == field.Type():
+		field.Set(v)
+	case v.Kind() == reflect.String:
+		parsedURL, err := url.Parse(v.String())
+		if err != nil {
+			return fmt.Errorf("invalid url: %w", err)
+		}
+		field.Set(reflect.ValueOf(*parsedURL))
+	default:
+		return fmt.Errorf("type mismatch: expected url.URL, got %T", value)
+	}
+	return nil
+}
+
+func setMapField(field reflect.Value, value interface{}, v reflect.Value) error {
+	if looksLikeJSON(v) {
+		return setJSONField(field, value)
+	}
+	if v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+	if v.Kind() == reflect.Map {
+		// maps of other types, e.g. pgtype.Hstore of hstore column or map[string]any, are converted element by element
+		// with the scalar setters. NULL values are zero values.
+		result := reflect.MakeMapWithSize(field.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key := reflect.New(field.Type().Key()).Elem()
+			if err := setFieldValue(key, iter.Key().Interface()); err != nil {
+				return fmt.Errorf("map key %v: %w", iter.Key(), err)
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if elemValue := iter.Value(); !isNilValue(elemValue) {
+				if err := setFieldValue(elem, elemValue.Interface()); err != nil {
+					return fmt.Errorf("map value of %v: %w", iter.Key(), err)
+				}
+			}
+			result.SetMapIndex(key, elem)
+		}
+		field.Set(result)
+		return nil
+	}
+	return fmt.Errorf("type mismatch: expected %s, got %T", field.Type(), value)
+}
+
+// isNilValue reports whether the value is nil interface or nil pointer
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	default:
+		return !v.IsValid()
+	}
+}
+
+// setBytesField assigns bytea and text values into []byte fields as a whole. json.RawMessage fields also take json
+// and jsonb values, which pgx decodes, encoded back into JSON.
+func setBytesField(field reflect.Value, value interface{}, v reflect.Value) error {
+	switch {
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		field.SetBytes(bytes.Clone(v.Bytes()))
+	case v.Kind() == reflect.String:
+		field.SetBytes([]byte(v.String()))
+	case field.Type() == reflect.TypeOf(json.RawMessage{}):
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %T as JSON: %w", value, err)
+		}
+		field.SetBytes(encoded)
+	default:
+		return fmt.Errorf("type mismatch: expected bytes, got %T", value)
+	}
 	return nil
 }
 
@@ -381,6 +1958,9 @@ func setSliceField(field reflect.Value, value interface{}, v reflect.Value) erro
 	}
 
 	elemType := field.Type().Elem()
+	if elemType.Kind() == reflect.Uint8 {
+		return setBytesField(field, value, v)
+	}
 
 	// Use existing slice or create if nil
 	slice := field
@@ -388,18 +1968,19 @@ func setSliceField(field reflect.Value, value interface{}, v reflect.Value) erro
 		slice = reflect.MakeSlice(field.Type(), 0, 0)
 	}
 
-	// Case: value is a slice
+	// Case: value is a slice, e.g. array column. Elements which cannot be assigned, like []interface{} elements pgx
+	// decodes arrays of some types into, are converted with the scalar setters. NULL elements are zero values.
 	if v.Kind() == reflect.Slice {
 		for i := 0; i < v.Len(); i++ {
 			elem := v.Index(i)
 
-			var newElem reflect.Value
+			newElem := reflect.New(elemType).Elem()
 			if elem.Type().AssignableTo(elemType) {
-				newElem = elem
-			} else if elem.Type().ConvertibleTo(elemType) {
-				newElem = elem.Convert(elemType)
-			} else {
-				return fmt.Errorf("cannot assign or convert %s to %s", elem.Type(), elemType)
+				newElem.Set(elem)
+			} else if elem.Kind() == reflect.Interface && elem.IsNil() {
+				// NULL element
+			} else if err := setFieldValue(newElem, elem.Interface()); err != nil {
+				return fmt.Errorf("array element %d: %w", i, err)
 			}
 
 			slice = reflect.Append(slice, newElem)
@@ -2,9 +2,20 @@ package mapper
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/pashagolub/pgxmock/v2"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +35,34 @@ func setupPostgresMock(t *testing.T, mockQuery string, rows [][]interface{}, col
 	return mock
 }
 
+// rawValueRows returns the raw values given by the test, as pgxmock formats nil values as "<nil>"
+type rawValueRows struct {
+	pgx.Rows
+	rawValues [][]byte
+}
+
+func (r *rawValueRows) RawValues() [][]byte {
+	return r.rawValues
+}
+
+// queryNullJSONColumns returns a row with id 1 and jsonb columns, which pgx decoded into nil from the raw values
+func queryNullJSONColumns(t *testing.T, columns []string, rawValues ...[]byte) pgx.Rows {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("unexpected error opening mock DB: %s", err)
+	}
+	descriptions := []pgconn.FieldDescription{{Name: "id", DataTypeOID: pgtype.Int8OID}}
+	values := []any{1}
+	for _, column := range columns {
+		descriptions = append(descriptions, pgconn.FieldDescription{Name: column, DataTypeOID: pgtype.JSONBOID})
+		values = append(values, nil)
+	}
+	mock.ExpectQuery("^SELECT (.+)$").WillReturnRows(mock.NewRowsWithColumnDefinition(descriptions...).AddRow(values...))
+	rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+	assert.NoError(t, err)
+	return &rawValueRows{Rows: rows, rawValues: append([][]byte{[]byte("1")}, rawValues...)}
+}
+
 func TestScanOne(t *testing.T) {
 	runSuccessfulTest := func(inputType reflect.Type, setupTestFn func() pgxmock.PgxConnIface, query string, expectedResult any) {
 		mock := setupTestFn()
@@ -85,6 +124,11 @@ func TestScanOne(t *testing.T) {
 			}
 			err = ScanOne(rows, &address{})
 			assert.EqualError(t, err, expectedError.Error())
+			assert.ErrorIs(t, err, ErrTooManyRows)
+			var tooManyRowsError *TooManyRowsError
+			if assert.ErrorAs(t, err, &tooManyRowsError) {
+				assert.Equal(t, reflect.TypeOf(user{}), tooManyRowsError.EntityType)
+			}
 		}
 
 		runAndAssertTooManyRowsTest(setupMultipleUsersForAddress, query, errors.New("Too many rows for entity(name=mapper.user)"))
@@ -227,6 +271,26 @@ func TestScanMany(t *testing.T) {
 		runSuccessfulTest(reflect.TypeOf([]OrgWithUserPointer{}), setupMockFunc, query, expectedOrgWithUserPointerResult)
 	})
 
+	t.Run("Maps pgx.Rows to slice of pointers", func(t *testing.T) {
+		const query = "SELECT * FROM address a LEFT JOIN users u on u.user_id = a.user_id"
+		type address struct {
+			AddressId uint   `primaryKey:"address_id"`
+			Street    string `db:"address_street"`
+			Owners    []user `relationship:"oneToMany"`
+		}
+		setupFn := func() pgxmock.PgxConnIface {
+			return setupPostgresMock(t, "^SELECT (.+) FROM address a LEFT JOIN users u on u.user_id = a.user_id$",
+				[][]interface{}{{1, "John", 1, "Street"}, {2, "Jane", 1, "Street"}, {1, "John", 2, "Avenue"}},
+				[]string{"user_id", "user_name", "address_id", "address_street"})
+		}
+
+		expectedResult := &[]*address{
+			{AddressId: 1, Street: "Street", Owners: []user{{UserId: 1, Name: "John"}, {UserId: 2, Name: "Jane"}}},
+			{AddressId: 2, Street: "Avenue", Owners: []user{{UserId: 1, Name: "John"}}},
+		}
+		runSuccessfulTest(reflect.TypeOf([]*address{}), setupFn, query, expectedResult)
+	})
+
 }
 
 func TestQueryWith_LeftJoinManyMatches(t *testing.T) {
@@ -274,3 +338,2190 @@ func TestQueryWith_LeftJoinManyMatches(t *testing.T) {
 		assert.Equal(t, expectedResult, result)
 	})
 }
+
+func TestScanOneWithJSONColumns(t *testing.T) {
+	type metadata struct {
+		Source string   `json:"source"`
+		Tags   []string `json:"tags"`
+	}
+
+	t.Run("Decodes JSON text into map and struct fields", func(t *testing.T) {
+		type document struct {
+			DocumentId uint           `primaryKey:"document_id"`
+			Attributes map[string]any `db:"attributes"`
+			Metadata   metadata       `db:"metadata"`
+		}
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+			[][]interface{}{{1, `{"color": "red"}`, []byte(`{"source": "import", "tags": ["a", "b"]}`)}},
+			[]string{"document_id", "attributes", "metadata"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+		assert.NoError(t, err)
+
+		var result document
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, document{
+			DocumentId: 1,
+			Attributes: map[string]any{"color": "red"},
+			Metadata:   metadata{Source: "import", Tags: []string{"a", "b"}},
+		}, result)
+	})
+
+	t.Run("Decodes jsonb tagged column already decoded by pgx", func(t *testing.T) {
+		type document struct {
+			DocumentId uint      `primaryKey:"document_id"`
+			Metadata   *metadata `db:"metadata,jsonb"`
+		}
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+			[][]interface{}{{1, map[string]any{"source": "api", "tags": []any{"c"}}}},
+			[]string{"document_id", "metadata"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+		assert.NoError(t, err)
+
+		var result document
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, document{DocumentId: 1, Metadata: &metadata{Source: "api", Tags: []string{"c"}}}, result)
+	})
+
+	t.Run("Decodes column already decoded by pgx into untagged struct field", func(t *testing.T) {
+		type document struct {
+			DocumentId uint     `primaryKey:"document_id"`
+			Metadata   metadata `db:"metadata"`
+			Names      []string `db:"names"`
+		}
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+			[][]interface{}{{1, map[string]any{"source": "api", "tags": []any{"c"}}, []any{"x", "y"}}},
+			[]string{"document_id", "metadata", "names"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+		assert.NoError(t, err)
+
+		var result document
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, document{DocumentId: 1, Metadata: metadata{Source: "api", Tags: []string{"c"}}, Names: []string{"x", "y"}}, result)
+	})
+
+	t.Run("Fails on value which is not a struct", func(t *testing.T) {
+		type document struct {
+			DocumentId uint     `primaryKey:"document_id"`
+			Metadata   metadata `db:"metadata"`
+		}
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$", [][]interface{}{{1, 42}}, []string{"document_id", "metadata"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+		assert.NoError(t, err)
+
+		err = ScanOne(rows, &document{})
+
+		assert.ErrorContains(t, err, "type mismatch: expected mapper.metadata, got int")
+	})
+
+	t.Run("Distinguishes SQL NULL from JSON null read as text", func(t *testing.T) {
+		type document struct {
+			DocumentId uint      `primaryKey:"document_id"`
+			Metadata   *metadata `db:"metadata,jsonb"`
+			Revision   metadata  `db:"revision,jsonb"`
+		}
+		scan := func(metadataValue, revisionValue any) document {
+			mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+				[][]interface{}{{1, metadataValue, revisionValue}},
+				[]string{"document_id", "metadata", "revision"})
+			rows, err := mock.Query(context.Background(),
+				"SELECT document_id, metadata::text AS metadata, revision::text AS revision FROM documents")
+			assert.NoError(t, err)
+
+			result := document{Metadata: &metadata{Source: "previous"}, Revision: metadata{Source: "previous"}}
+			err = ScanOne(rows, &result)
+			assert.NoError(t, err)
+			return result
+		}
+
+		sqlNullResult := scan(nil, nil)
+		assert.Equal(t, &metadata{Source: "previous"}, sqlNullResult.Metadata)
+		assert.Equal(t, metadata{Source: "previous"}, sqlNullResult.Revision)
+
+		jsonNullResult := scan([]byte("null"), "null")
+		assert.Nil(t, jsonNullResult.Metadata)
+		assert.Equal(t, metadata{}, jsonNullResult.Revision)
+	})
+
+	t.Run("Distinguishes SQL NULL from JSON null of jsonb column", func(t *testing.T) {
+		type document struct {
+			DocumentId uint           `primaryKey:"id"`
+			Metadata   *metadata      `db:"metadata,jsonb"`
+			Revision   metadata       `db:"revision,jsonb"`
+			Attributes map[string]any `db:"attributes"`
+		}
+		columns := []string{"metadata", "revision", "attributes"}
+		previous := func() document {
+			return document{Metadata: &metadata{Source: "previous"}, Revision: metadata{Source: "previous"},
+				Attributes: map[string]any{"color": "red"}}
+		}
+
+		sqlNullResult := previous()
+		err := ScanOne(queryNullJSONColumns(t, columns, nil, nil, nil), &sqlNullResult)
+		assert.NoError(t, err)
+		assert.Equal(t, document{DocumentId: 1, Metadata: &metadata{Source: "previous"},
+			Revision: metadata{Source: "previous"}, Attributes: map[string]any{"color": "red"}}, sqlNullResult)
+
+		jsonNullResult := previous()
+		err = ScanOne(queryNullJSONColumns(t, columns, []byte("\x01null"), []byte(" null"), []byte("\x01null")),
+			&jsonNullResult)
+		assert.NoError(t, err)
+		assert.Equal(t, document{DocumentId: 1, Attributes: map[string]any{"color": "red"}}, jsonNullResult)
+	})
+
+	t.Run("Fails on invalid JSON in jsonb tagged column", func(t *testing.T) {
+		type document struct {
+			DocumentId uint     `primaryKey:"document_id"`
+			Metadata   metadata `db:"metadata,jsonb"`
+		}
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+			[][]interface{}{{1, "not json"}},
+			[]string{"document_id", "metadata"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+		assert.NoError(t, err)
+
+		var result document
+		err = ScanOne(rows, &result)
+
+		assert.ErrorContains(t, err, "failed to map column metadata")
+	})
+}
+
+func TestScanOneWithDerivedColumnNames(t *testing.T) {
+	type account struct {
+		AccountId   uint `primaryKey:"account_id"`
+		DisplayName string
+		CreatedAt   time.Time
+		Email       string `db:"email_address"`
+		internal    string //nolint:unused // Suppress U1000 from staticcheck
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("Maps untagged fields using the naming strategy", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM accounts$",
+			[][]interface{}{{1, "John", createdAt, "john@example.com", "secret"}},
+			[]string{"account_id", "display_name", "created_at", "email_address", "internal"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM accounts")
+		assert.NoError(t, err)
+
+		var result account
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, account{AccountId: 1, DisplayName: "John", CreatedAt: createdAt, Email: "john@example.com"}, result)
+	})
+
+	t.Run("Uses overridden naming strategy", func(t *testing.T) {
+		type profile struct {
+			ProfileId uint `primaryKey:"profile_id"`
+			Nickname  string
+		}
+		defaultStrategy := NamingStrategy
+		NamingStrategy = func(fieldName string) string { return "p_" + SnakeCase(fieldName) }
+		defer func() { NamingStrategy = defaultStrategy }()
+
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM profiles$",
+			[][]interface{}{{1, "johnny"}},
+			[]string{"profile_id", "p_nickname"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM profiles")
+		assert.NoError(t, err)
+
+		var result profile
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, profile{ProfileId: 1, Nickname: "johnny"}, result)
+	})
+}
+
+type orderStatus struct {
+	label string
+}
+
+func (s orderStatus) String() string {
+	return s.label
+}
+
+func TestScanOneWithTextValueSources(t *testing.T) {
+	type order struct {
+		OrderId  uint   `primaryKey:"order_id"`
+		Status   string `db:"status"`
+		Category string `db:"category"`
+	}
+
+	t.Run("Maps fmt.Stringer and pgtype.TextValuer sources into string fields", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+			[][]interface{}{{1, orderStatus{label: "shipped"}, pgtype.Text{String: "books", Valid: true}}},
+			[]string{"order_id", "status", "category"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+		assert.NoError(t, err)
+
+		var result order
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, order{OrderId: 1, Status: "shipped", Category: "books"}, result)
+	})
+
+	t.Run("Fails when source has no text representation", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+			[][]interface{}{{1, []int{1}, "books"}},
+			[]string{"order_id", "status", "category"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+		assert.NoError(t, err)
+
+		var result order
+		err = ScanOne(rows, &result)
+
+		assert.ErrorContains(t, err, "type mismatch: expected string, got []int")
+	})
+}
+
+func TestScanOneSkipsExcludedFields(t *testing.T) {
+	type invoice struct {
+		InvoiceId uint    `primaryKey:"invoice_id"`
+		Amount    float64 `db:"amount"`
+		Total     float64 `db:"-"`
+		Customer  *user   `relationship:"-"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM invoices$",
+		[][]interface{}{{1, 10.5, 99.0, 1, "John"}},
+		[]string{"invoice_id", "amount", "total", "user_id", "user_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM invoices")
+	assert.NoError(t, err)
+
+	var result invoice
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, invoice{InvoiceId: 1, Amount: 10.5}, result)
+
+	mappingInfo, _ := GetEntityGraphMappingInfo(reflect.TypeOf(invoice{}))
+	assert.NotContains(t, mappingInfo.FieldMapping, "total")
+	assert.Empty(t, mappingInfo.Relationships)
+}
+
+func TestScanOneWithURLFields(t *testing.T) {
+	type website struct {
+		WebsiteId uint     `primaryKey:"website_id"`
+		Address   url.URL  `db:"address"`
+		Logo      *url.URL `db:"logo"`
+	}
+
+	t.Run("Parses text columns into url.URL fields", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM websites$",
+			[][]interface{}{{1, "https://example.com/home?lang=en", "https://cdn.example.com/logo.png"}},
+			[]string{"website_id", "address", "logo"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM websites")
+		assert.NoError(t, err)
+
+		var result website
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/home?lang=en", result.Address.String())
+		assert.Equal(t, "cdn.example.com", result.Logo.Host)
+	})
+
+	t.Run("Fails on invalid url", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM websites$",
+			[][]interface{}{{1, "http://[::1", nil}},
+			[]string{"website_id", "address", "logo"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM websites")
+		assert.NoError(t, err)
+
+		var result website
+		err = ScanOne(rows, &result)
+
+		assert.ErrorContains(t, err, "failed to map column address: invalid url")
+	})
+}
+
+func TestScanOneWithExtraColumns(t *testing.T) {
+	type event struct {
+		EventId    uint           `primaryKey:"event_id"`
+		Name       string         `db:"event_name"`
+		Attributes map[string]any `db:",extra"`
+		Creator    *user          `relationship:"oneToOne"`
+	}
+
+	t.Run("Collects unmapped columns into extra map", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM events$",
+			[][]interface{}{{1, "launch", 1, "John", "eu-west", nil}},
+			[]string{"event_id", "event_name", "user_id", "user_name", "region", "deleted_at"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM events")
+		assert.NoError(t, err)
+
+		var result event
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, event{
+			EventId:    1,
+			Name:       "launch",
+			Attributes: map[string]any{"region": "eu-west", "deleted_at": nil},
+			Creator:    &user{UserId: 1, Name: "John"},
+		}, result)
+	})
+
+	t.Run("Fails when extra field is not a map", func(t *testing.T) {
+		type invalidEvent struct {
+			EventId    uint   `primaryKey:"event_id"`
+			Attributes string `db:",extra"`
+		}
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM events$",
+			[][]interface{}{{1}}, []string{"event_id"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM events")
+		assert.NoError(t, err)
+
+		err = ScanOne(rows, &invalidEvent{})
+		assert.EqualError(t, err, "extra field Attributes must be a map with string keys, e.g. map[string]any")
+	})
+
+	t.Run("Collects unmapped columns into map of strings", func(t *testing.T) {
+		type label string
+		type folder struct {
+			FolderId uint             `primaryKey:"folder_id"`
+			Owner    *user            `relationship:"oneToOne" prefix:"owner_"`
+			Labels   map[label]string `db:",extra"`
+		}
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM folders$",
+			[][]interface{}{{2, 1, "John", "blue"}}, []string{"folder_id", "owner_user_id", "owner_user_name", "color"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM folders")
+		assert.NoError(t, err)
+
+		var result folder
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, folder{FolderId: 2, Owner: &user{UserId: 1, Name: "John"}, Labels: map[label]string{"color": "blue"}}, result)
+		mappingInfo, _ := GetEntityGraphMappingInfo(reflect.TypeOf(folder{}))
+		assert.Equal(t, map[string]struct{}{"folder_id": {}, "owner_user_id": {}, "owner_user_name": {}}, mappingInfo.GraphColumns)
+	})
+}
+
+func TestScanManyWithManyToManyRelationship(t *testing.T) {
+	type role struct {
+		RoleId uint   `primaryKey:"role_id"`
+		Name   string `db:"role_name"`
+	}
+	type userWithRoles struct {
+		UserId uint   `primaryKey:"user_id"`
+		Name   string `db:"user_name"`
+		Roles  []role `relationship:"manyToMany"`
+	}
+	const query = "SELECT u.user_id, u.user_name, r.role_id, r.role_name FROM users u " +
+		"LEFT JOIN user_roles ur ON ur.user_id = u.user_id LEFT JOIN roles r ON r.role_id = ur.role_id"
+
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users u (.+)$",
+		[][]interface{}{
+			{1, "John", 1, "admin"},
+			{1, "John", 2, "editor"},
+			{2, "Jane", 1, "admin"},
+			{1, "John", 1, "admin"},
+		},
+		[]string{"user_id", "user_name", "role_id", "role_name"})
+	rows, err := mock.Query(context.Background(), query)
+	assert.NoError(t, err)
+
+	var result []userWithRoles
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []userWithRoles{
+		{UserId: 1, Name: "John", Roles: []role{{RoleId: 1, Name: "admin"}, {RoleId: 2, Name: "editor"}}},
+		{UserId: 2, Name: "Jane", Roles: []role{{RoleId: 1, Name: "admin"}}},
+	}, result)
+}
+
+func TestScanReturnsErrorForMalformedEntity(t *testing.T) {
+	type malformed struct {
+		FirstId  uint `primaryKey:"first_id"`
+		SecondId uint `primaryKey:"second_id"`
+	}
+	type parent struct {
+		ParentId uint        `primaryKey:"parent_id"`
+		Children []malformed `relationship:"oneToMany"`
+	}
+	setupFn := func() pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM malformed$",
+			[][]interface{}{{1, 1, 2}}, []string{"parent_id", "first_id", "second_id"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM malformed")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	err := ScanOne(setupFn(), &malformed{})
+	assert.EqualError(t, err, "multiple primary key fields found")
+
+	var result []parent
+	err = ScanMany(setupFn(), &result)
+	assert.EqualError(t, err, "multiple primary key fields found")
+
+	_, exists := GetEntityGraphMappingInfo(reflect.TypeOf(parent{}))
+	assert.False(t, exists)
+}
+
+func TestMustAnalyze(t *testing.T) {
+	type malformed struct {
+		FirstId  uint `primaryKey:"first_id"`
+		SecondId uint `primaryKey:"second_id"`
+	}
+
+	assert.NotPanics(t, func() { MustAnalyze(reflect.TypeOf(&user{})) })
+	assert.PanicsWithError(t, "multiple primary key fields found", func() { MustAnalyze(reflect.TypeOf(malformed{})) })
+}
+
+func TestRegisterEntity(t *testing.T) {
+	type registered struct {
+		RegisteredId uint   `primaryKey:"registered_id"`
+		Name         string `db:"name"`
+	}
+	type malformed struct {
+		FirstId  uint `primaryKey:"first_id"`
+		SecondId uint `primaryKey:"second_id"`
+	}
+
+	assert.NoError(t, RegisterEntity(reflect.TypeOf(&registered{})))
+	mappingInfo, exists := GetEntityGraphMappingInfo(reflect.TypeOf(registered{}))
+	assert.True(t, exists)
+	assert.Equal(t, []int{1}, mappingInfo.FieldMapping["name"])
+
+	assert.EqualError(t, RegisterEntity(reflect.TypeOf(malformed{})), "multiple primary key fields found")
+	assert.EqualError(t, RegisterEntity(reflect.TypeOf("")), "entity(string) must be a struct")
+}
+
+func TestScanOneWithNumericNaN(t *testing.T) {
+	type measurement struct {
+		MeasurementId uint    `primaryKey:"measurement_id"`
+		Value         float64 `db:"value"`
+	}
+	type counter struct {
+		CounterId uint `primaryKey:"counter_id"`
+		Value     int  `db:"value"`
+	}
+	nan := pgtype.Numeric{NaN: true, Valid: true}
+
+	t.Run("Maps numeric NaN into float64 as math.NaN", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM measurements$",
+			[][]interface{}{{1, nan}}, []string{"measurement_id", "value"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM measurements")
+		assert.NoError(t, err)
+
+		var result measurement
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.True(t, math.IsNaN(result.Value))
+	})
+
+	t.Run("Maps numeric into float64", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM measurements$",
+			[][]interface{}{{1, pgtype.Numeric{Int: big.NewInt(1250), Exp: -2, Valid: true}}}, []string{"measurement_id", "value"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM measurements")
+		assert.NoError(t, err)
+
+		var result measurement
+		err = ScanOne(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 12.5, result.Value)
+	})
+
+	t.Run("Fails to map numeric NaN into int", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM counters$",
+			[][]interface{}{{1, nan}}, []string{"counter_id", "value"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM counters")
+		assert.NoError(t, err)
+
+		var result counter
+		err = ScanOne(rows, &result)
+
+		assert.EqualError(t, err, "failed to map column value: cannot assign numeric NaN to int field")
+	})
+}
+
+func TestScanWithCancelledContext(t *testing.T) {
+	setupFn := func() pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+			[][]interface{}{{1, "John"}, {2, "Jane"}}, []string{"user_id", "user_name"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+		assert.NoError(t, err)
+		return rows
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var one user
+	err := ScanOneContext(ctx, setupFn(), &one)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, one)
+
+	var many []user
+	err = ScanManyContext(ctx, setupFn(), &many)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, many)
+}
+
+func TestScanManyMaps(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+		[][]interface{}{{1, "John", createdAt}, {2, nil, nil}},
+		[]string{"user_id", "user_name", "created_at"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+	assert.NoError(t, err)
+
+	result, err := ScanManyMaps(rows)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"user_id": 1, "user_name": "John", "created_at": createdAt},
+		{"user_id": 2, "user_name": nil, "created_at": nil},
+	}, result)
+}
+
+func TestScanManyIntoMaps(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+		[][]interface{}{{1, "John"}, {1, "Mary"}},
+		[]string{"user_id", "user_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+	assert.NoError(t, err)
+
+	var result []map[string]any
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"user_id": 1, "user_name": "John"},
+		{"user_id": 1, "user_name": "Mary"},
+	}, result)
+}
+
+func TestScanFirst(t *testing.T) {
+	type address struct {
+		AddressId uint   `primaryKey:"address_id"`
+		Street    string `db:"address_street"`
+		Owner     *user  `relationship:"oneToOne"`
+		Visitors  []user `relationship:"oneToMany"`
+	}
+	const query = "SELECT * FROM address a LEFT JOIN users u on u.user_id = a.user_id"
+
+	t.Run("Maps only the first entity", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM address a LEFT JOIN users u on u.user_id = a.user_id$",
+			[][]interface{}{{1, "John", 1, "Street"}, {2, "Jane", 2, "Avenue"}},
+			[]string{"user_id", "user_name", "address_id", "address_street"})
+		rows, err := mock.Query(context.Background(), query)
+		assert.NoError(t, err)
+
+		var result address
+		err = ScanFirst(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, address{
+			AddressId: 1,
+			Street:    "Street",
+			Owner:     &user{UserId: 1, Name: "John"},
+			Visitors:  []user{{UserId: 1, Name: "John"}},
+		}, result)
+	})
+
+	t.Run("Maps all rows of the first entity", func(t *testing.T) {
+		type street struct {
+			AddressId uint   `primaryKey:"address_id"`
+			Street    string `db:"address_street"`
+			Residents []user `relationship:"oneToMany"`
+		}
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM address a LEFT JOIN users u on u.user_id = a.user_id$",
+			[][]interface{}{{1, "John", 1, "Street"}, {2, "Jane", 1, "Street"}, {3, "Jack", 2, "Avenue"}},
+			[]string{"user_id", "user_name", "address_id", "address_street"})
+		rows, err := mock.Query(context.Background(), query)
+		assert.NoError(t, err)
+
+		var result street
+		err = ScanFirst(rows, &result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, street{
+			AddressId: 1,
+			Street:    "Street",
+			Residents: []user{{UserId: 1, Name: "John"}, {UserId: 2, Name: "Jane"}},
+		}, result)
+	})
+
+	t.Run("Returns ErrNoRows when there are no rows", func(t *testing.T) {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM users$", [][]interface{}{}, []string{"user_id", "user_name"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+		assert.NoError(t, err)
+
+		var result user
+		err = ScanFirst(rows, &result)
+
+		assert.ErrorIs(t, err, ErrNoRows)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+}
+
+func TestScanOneWithTimeLocation(t *testing.T) {
+	type session struct {
+		SessionId uint       `primaryKey:"session_id"`
+		StartedAt time.Time  `db:"started_at"`
+		EndedAt   *time.Time `db:"ended_at"`
+		ExpiresAt time.Time  `db:"expires_at"`
+	}
+	location := time.FixedZone("UTC+3", 3*60*60)
+	TimeLocation = location
+	defer func() { TimeLocation = nil }()
+
+	startedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM sessions$",
+		[][]interface{}{{1, startedAt, startedAt, time.Time{}}},
+		[]string{"session_id", "started_at", "ended_at", "expires_at"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM sessions")
+	assert.NoError(t, err)
+
+	var result session
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, location, result.StartedAt.Location())
+	assert.Equal(t, 13, result.StartedAt.Hour())
+	assert.True(t, startedAt.Equal(result.StartedAt))
+	assert.Equal(t, location, result.EndedAt.Location())
+	assert.True(t, result.ExpiresAt.IsZero())
+}
+
+type Audit struct {
+	CreatedBy string    `db:"created_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type versioned struct {
+	Version int `db:"version"`
+}
+
+func TestScanManyWithEmbeddedStructs(t *testing.T) {
+	type document struct {
+		Audit
+		versioned
+		DocumentId uint   `primaryKey:"document_id"`
+		Title      string `db:"title"`
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+		[][]interface{}{{1, "Report", "john", createdAt, 3}, {2, "Invoice", "jane", createdAt, 1}},
+		[]string{"document_id", "title", "created_by", "created_at", "version"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+	assert.NoError(t, err)
+
+	var result []document
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []document{
+		{Audit: Audit{CreatedBy: "john", CreatedAt: createdAt}, versioned: versioned{Version: 3}, DocumentId: 1, Title: "Report"},
+		{Audit: Audit{CreatedBy: "jane", CreatedAt: createdAt}, versioned: versioned{Version: 1}, DocumentId: 2, Title: "Invoice"},
+	}, result)
+
+	mappingInfo, _ := GetEntityGraphMappingInfo(reflect.TypeOf(document{}))
+	assert.Equal(t, []int{0, 1}, mappingInfo.FieldMapping["created_at"])
+}
+
+func TestScanManyWithEmbeddedStructPointer(t *testing.T) {
+	type document struct {
+		*Audit
+		DocumentId uint   `primaryKey:"document_id"`
+		Title      string `db:"title"`
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+		[][]interface{}{{1, "Report", "john", createdAt}, {2, "Draft", nil, nil}},
+		[]string{"document_id", "title", "created_by", "created_at"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+	assert.NoError(t, err)
+
+	var result []document
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []document{
+		{Audit: &Audit{CreatedBy: "john", CreatedAt: createdAt}, DocumentId: 1, Title: "Report"},
+		{DocumentId: 2, Title: "Draft"},
+	}, result)
+
+	args, err := BindStruct(result[1])
+	assert.NoError(t, err)
+	assert.Equal(t, pgx.NamedArgs{"document_id": uint(2), "title": "Draft", "created_by": nil, "created_at": nil}, args)
+}
+
+func TestScanOneWithRanges(t *testing.T) {
+	type reservation struct {
+		ReservationId uint                `primaryKey:"reservation_id"`
+		Seats         Range[int64]        `db:"seats"`
+		Rooms         pgtype.Range[int32] `db:"rooms"`
+		Period        *Range[time.Time]   `db:"period"`
+	}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM reservations$",
+		[][]interface{}{{
+			1,
+			pgtype.Range[any]{Lower: int64(10), LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true},
+			pgtype.Range[any]{Lower: int32(1), Upper: int32(5), LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+			pgtype.Range[any]{Lower: from, LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true},
+		}},
+		[]string{"reservation_id", "seats", "rooms", "period"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM reservations")
+	assert.NoError(t, err)
+
+	var result reservation
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Range[int64]{Lower: 10, LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true}, result.Seats)
+	assert.Equal(t, pgtype.Range[int32]{Lower: 1, Upper: 5, LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true}, result.Rooms)
+	assert.Equal(t, &Range[time.Time]{Lower: from, LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true}, result.Period)
+}
+
+func TestScanOneJoinsArrayIntoString(t *testing.T) {
+	type article struct {
+		ArticleId uint   `primaryKey:"article_id"`
+		Tags      string `db:"tags,join"`
+		Scores    string `db:"scores,join"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM articles$",
+		[][]interface{}{{1, []interface{}{"go", nil, "postgres"}, []int32{1, 2}}},
+		[]string{"article_id", "tags", "scores"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM articles")
+	assert.NoError(t, err)
+
+	var result article
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "go,postgres", result.Tags)
+	assert.Equal(t, "1,2", result.Scores)
+}
+
+func TestScanOneJoinsArrayWithCustomSeparator(t *testing.T) {
+	type article struct {
+		ArticleId uint   `primaryKey:"article_id"`
+		Tags      string `db:"tags,join"`
+	}
+	JoinSeparator = "; "
+	defer func() { JoinSeparator = "," }()
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM articles$",
+		[][]interface{}{{1, []string{"go", "postgres"}}},
+		[]string{"article_id", "tags"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM articles")
+	assert.NoError(t, err)
+
+	var result article
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "go; postgres", result.Tags)
+}
+
+func TestScanManyReturnsRowsError(t *testing.T) {
+	type user struct {
+		UserId uint   `primaryKey:"user_id"`
+		Name   string `db:"name"`
+	}
+	mock, err := pgxmock.NewConn()
+	assert.NoError(t, err)
+	rowsError := errors.New("terminating connection due to administrator command")
+	mock.ExpectQuery("^SELECT (.+) FROM users$").WillReturnRows(
+		pgxmock.NewRows([]string{"user_id", "name"}).AddRow(1, "John").RowError(0, rowsError))
+	rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+	assert.NoError(t, err)
+
+	var result []user
+	err = ScanMany(rows, &result)
+
+	assert.ErrorIs(t, err, rowsError)
+	assert.Empty(t, result)
+}
+
+func TestScanFirstReturnsRowsError(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.NoError(t, err)
+	rowsError := errors.New("terminating connection due to administrator command")
+	mock.ExpectQuery("^SELECT (.+) FROM users$").WillReturnRows(
+		pgxmock.NewRows([]string{"user_id", "user_name"}).AddRow(1, "John").RowError(1, rowsError))
+	rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+	assert.NoError(t, err)
+
+	var result user
+	err = ScanFirst(rows, &result)
+
+	assert.ErrorIs(t, err, rowsError)
+}
+
+type Customer struct {
+	CustomerId uint   `primaryKey:"customer_id"`
+	Name       string `db:"customer_name"`
+}
+
+func TestScanManyWithAnonymousRelationship(t *testing.T) {
+	type order struct {
+		OrderId  uint `primaryKey:"order_id"`
+		Customer `relationship:"oneToOne"`
+		Total    int `db:"total"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+		[][]interface{}{
+			{1, 100, 10, "Alice"},
+			{2, 200, 11, "Bob"},
+		},
+		[]string{"order_id", "total", "customer_id", "customer_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+	assert.NoError(t, err)
+
+	var result []order
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []order{
+		{OrderId: 1, Total: 100, Customer: Customer{CustomerId: 10, Name: "Alice"}},
+		{OrderId: 2, Total: 200, Customer: Customer{CustomerId: 11, Name: "Bob"}},
+	}, result)
+	mappingInfo, _ := GetEntityGraphMappingInfo(reflect.TypeOf(order{}))
+	assert.NotContains(t, mappingInfo.FieldMapping, "customer_name")
+}
+
+func TestScanManyLenientSkipsRowsWhichFailToMap(t *testing.T) {
+	type user struct {
+		UserId uint   `primaryKey:"user_id"`
+		Name   string `db:"name"`
+		Age    int    `db:"age"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+		[][]interface{}{
+			{1, "John", 30},
+			{2, "Jane", "not a number"},
+			{3, "Mark", 40},
+		},
+		[]string{"user_id", "name", "age"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+	assert.NoError(t, err)
+
+	var result []user
+	err = ScanManyLenient(rows, &result)
+
+	assert.ErrorContains(t, err, "row 1: failed to map column age")
+	assert.Equal(t, []user{{UserId: 1, Name: "John", Age: 30}, {UserId: 3, Name: "Mark", Age: 40}}, result)
+}
+
+func TestScanOneWithTimeOfDay(t *testing.T) {
+	type shift struct {
+		ShiftId  uint          `primaryKey:"shift_id"`
+		StartsAt TimeOfDay     `db:"starts_at"`
+		EndsAt   *TimeOfDay    `db:"ends_at"`
+		Break    time.Duration `db:"break_at"`
+	}
+	startsAt := (13*time.Hour + 45*time.Minute).Microseconds()
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM shifts$",
+		[][]interface{}{{1, pgtype.Time{Microseconds: startsAt, Valid: true}, "21:30:15.5+02", pgtype.Time{Microseconds: startsAt, Valid: true}}},
+		[]string{"shift_id", "starts_at", "ends_at", "break_at"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM shifts")
+	assert.NoError(t, err)
+
+	var result shift
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, TimeOfDay{Hour: 13, Minute: 45}, result.StartsAt)
+	assert.Equal(t, "13:45:00", result.StartsAt.String())
+	assert.Equal(t, &TimeOfDay{Hour: 21, Minute: 30, Second: 15, Microsecond: 500000}, result.EndsAt)
+	assert.Equal(t, 13*time.Hour+45*time.Minute, result.Break)
+}
+
+func TestScanOneWithCompositeArray(t *testing.T) {
+	type orderLine struct {
+		Product  string `db:"product"`
+		Quantity int    `db:"quantity"`
+	}
+	type order struct {
+		OrderId  uint        `primaryKey:"order_id"`
+		Lines    []orderLine `db:"lines,composite"`
+		Shipping *orderLine  `db:"shipping,composite"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+		[][]interface{}{{
+			1,
+			[]any{
+				map[string]any{"product": "keyboard", "quantity": int32(2)},
+				nil,
+				map[string]any{"product": "mouse", "quantity": int32(1)},
+			},
+			map[string]any{"product": "courier", "quantity": nil},
+		}},
+		[]string{"order_id", "lines", "shipping"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+	assert.NoError(t, err)
+
+	var result order
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []orderLine{{Product: "keyboard", Quantity: 2}, {}, {Product: "mouse", Quantity: 1}}, result.Lines)
+	assert.Equal(t, &orderLine{Product: "courier"}, result.Shipping)
+}
+
+func TestScanScalar(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT count(.+) FROM users$", [][]interface{}{{int64(42)}}, []string{"count"})
+	rows, err := mock.Query(context.Background(), "SELECT count(*) FROM users")
+	assert.NoError(t, err)
+
+	var count int
+	err = ScanScalar(rows, &count)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, count)
+}
+
+func TestScanScalarIntoTime(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT max(.+) FROM users$", [][]interface{}{{createdAt}}, []string{"max"})
+	rows, err := mock.Query(context.Background(), "SELECT max(created_at) FROM users")
+	assert.NoError(t, err)
+
+	var latest time.Time
+	err = ScanScalar(rows, &latest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, createdAt, latest)
+}
+
+func TestScanScalarIntoScanner(t *testing.T) {
+	setupFn := func(value any) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT max(.+) FROM users$", [][]interface{}{{value}}, []string{"max"})
+		rows, err := mock.Query(context.Background(), "SELECT max(user_id) FROM users")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var maxId sql.NullInt64
+	err := ScanScalar(setupFn(int64(42)), &maxId)
+	assert.NoError(t, err)
+	assert.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, maxId)
+
+	err = ScanScalar(setupFn(nil), &maxId)
+	assert.NoError(t, err)
+	assert.Equal(t, sql.NullInt64{}, maxId)
+}
+
+func TestScanManyIntoScannerSlice(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users$", [][]interface{}{{"john"}, {nil}}, []string{"nickname"})
+	rows, err := mock.Query(context.Background(), "SELECT nickname FROM users")
+	assert.NoError(t, err)
+
+	var nicknames []sql.NullString
+	err = ScanMany(rows, &nicknames)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []sql.NullString{{String: "john", Valid: true}, {}}, nicknames)
+}
+
+func TestScanScalarErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        [][]interface{}
+		columns     []string
+		expectedErr string
+	}{
+		{name: "no rows", rows: [][]interface{}{}, columns: []string{"id"}, expectedErr: ErrNoRows.Error()},
+		{name: "many rows", rows: [][]interface{}{{"a"}, {"b"}}, columns: []string{"id"}, expectedErr: "Too many rows for entity(name=string)"},
+		{name: "many columns", rows: [][]interface{}{{1, "John"}}, columns: []string{"id", "name"}, expectedErr: "scalar query must return exactly one column, got 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := setupPostgresMock(t, "^SELECT (.+) FROM users$", test.rows, test.columns)
+			rows, err := mock.Query(context.Background(), "SELECT id FROM users")
+			assert.NoError(t, err)
+
+			var id string
+			err = ScanScalar(rows, &id)
+
+			assert.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestScanOneWithColumnTransform(t *testing.T) {
+	type account struct {
+		AccountId uint   `primaryKey:"account_id"`
+		Email     string `db:"account_email"`
+	}
+	RegisterColumnTransform("account_email", func(value any) any {
+		if email, ok := value.(string); ok {
+			return strings.ToLower(email)
+		}
+		return value
+	})
+	defer columnTransforms.Delete("account_email")
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM accounts$",
+		[][]interface{}{{1, "John.Doe@Example.COM"}},
+		[]string{"account_id", "account_email"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM accounts")
+	assert.NoError(t, err)
+
+	var result account
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "john.doe@example.com", result.Email)
+}
+
+func TestScanManyIntoPrimitiveSlice(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT id FROM users$", [][]interface{}{{int32(3)}, {int32(1)}, {int32(2)}}, []string{"id"})
+	rows, err := mock.Query(context.Background(), "SELECT id FROM users")
+	assert.NoError(t, err)
+
+	var ids []int
+	err = ScanMany(rows, &ids)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 1, 2}, ids)
+}
+
+func TestScanManyIntoPointerSliceWithNulls(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT name FROM users$", [][]interface{}{{"John"}, {nil}}, []string{"name"})
+	rows, err := mock.Query(context.Background(), "SELECT name FROM users")
+	assert.NoError(t, err)
+
+	var names []*string
+	err = ScanMany(rows, &names)
+
+	assert.NoError(t, err)
+	assert.Len(t, names, 2)
+	assert.Equal(t, "John", *names[0])
+	assert.Nil(t, names[1])
+}
+
+func TestScanManyIntoPrimitiveSliceRequiresOneColumn(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users$", [][]interface{}{{1, "John"}}, []string{"id", "name"})
+	rows, err := mock.Query(context.Background(), "SELECT id, name FROM users")
+	assert.NoError(t, err)
+
+	var ids []int
+	err = ScanMany(rows, &ids)
+
+	assert.EqualError(t, err, "scanning into []int requires exactly one column, got 2")
+}
+
+func TestScanManyWithCompositePrimaryKeySharingRelationshipColumns(t *testing.T) {
+	type role struct {
+		RoleId uint   `primaryKey:"role_id"`
+		Name   string `db:"role_name"`
+	}
+	type member struct {
+		UserId uint   `primaryKey:"user_id"`
+		Name   string `db:"user_name"`
+	}
+	type membership struct {
+		RoleId uint      `primaryKey:"role_id,composite"`
+		UserId uint      `primaryKey:"user_id,composite"`
+		Since  time.Time `db:"since"`
+		Role   role      `relationship:"oneToOne"`
+		User   *member   `relationship:"oneToOne"`
+	}
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM memberships$",
+		[][]interface{}{
+			{1, 10, since, "admin", "John"},
+			{1, 11, since, "admin", "Jane"},
+			{2, 10, since, "viewer", "John"},
+		},
+		[]string{"role_id", "user_id", "since", "role_name", "user_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM memberships")
+	assert.NoError(t, err)
+
+	var result []membership
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []membership{
+		{RoleId: 1, UserId: 10, Since: since, Role: role{RoleId: 1, Name: "admin"}, User: &member{UserId: 10, Name: "John"}},
+		{RoleId: 1, UserId: 11, Since: since, Role: role{RoleId: 1, Name: "admin"}, User: &member{UserId: 11, Name: "Jane"}},
+		{RoleId: 2, UserId: 10, Since: since, Role: role{RoleId: 2, Name: "viewer"}, User: &member{UserId: 10, Name: "John"}},
+	}, result)
+}
+
+func TestScanManyKeepsChildOrderWithInterleavedParents(t *testing.T) {
+	type tag struct {
+		TagId uint   `primaryKey:"tag_id"`
+		Label string `db:"label"`
+	}
+	type item struct {
+		ItemId uint   `primaryKey:"item_id"`
+		Name   string `db:"item_name"`
+		Tags   []tag  `relationship:"oneToMany"`
+	}
+	type order struct {
+		OrderId uint   `primaryKey:"order_id"`
+		Items   []item `relationship:"oneToMany"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+		[][]interface{}{
+			{1, 12, "mouse", 100, "red"},
+			{2, 20, "screen", 101, "blue"},
+			{1, 11, "keyboard", 102, "green"},
+			{1, 12, "mouse", 103, "small"},
+			{2, 20, "screen", 100, "red"},
+		},
+		[]string{"order_id", "item_id", "item_name", "tag_id", "label"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+	assert.NoError(t, err)
+
+	var result []order
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []order{
+		{OrderId: 1, Items: []item{
+			{ItemId: 12, Name: "mouse", Tags: []tag{{TagId: 100, Label: "red"}, {TagId: 103, Label: "small"}}},
+			{ItemId: 11, Name: "keyboard", Tags: []tag{{TagId: 102, Label: "green"}}},
+		}},
+		{OrderId: 2, Items: []item{
+			{ItemId: 20, Name: "screen", Tags: []tag{{TagId: 101, Label: "blue"}, {TagId: 100, Label: "red"}}},
+		}},
+	}, result)
+}
+
+func TestScanOneDeduplicatesChildrenOfCartesianProduct(t *testing.T) {
+	type item struct {
+		ItemId uint   `primaryKey:"item_id"`
+		Name   string `db:"item_name"`
+	}
+	type payment struct {
+		PaymentId uint `primaryKey:"payment_id"`
+		Amount    int  `db:"amount"`
+	}
+	type order struct {
+		OrderId  uint      `primaryKey:"order_id"`
+		Items    []item    `relationship:"oneToMany"`
+		Payments []payment `relationship:"oneToMany"`
+	}
+	// LEFT JOIN of items and payments returns every item with every payment
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+		[][]interface{}{
+			{1, 10, "keyboard", 100, 30},
+			{1, 10, "keyboard", 101, 20},
+			{1, 11, "mouse", 100, 30},
+			{1, 11, "mouse", 101, 20},
+			{1, 12, "screen", 100, 30},
+			{1, 12, "screen", 101, 20},
+		},
+		[]string{"order_id", "item_id", "item_name", "payment_id", "amount"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+	assert.NoError(t, err)
+
+	var result order
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []item{{ItemId: 10, Name: "keyboard"}, {ItemId: 11, Name: "mouse"}, {ItemId: 12, Name: "screen"}}, result.Items)
+	assert.Equal(t, []payment{{PaymentId: 100, Amount: 30}, {PaymentId: 101, Amount: 20}}, result.Payments)
+}
+
+func TestScanManySkipsChildrenWithNullKey(t *testing.T) {
+	type comment struct {
+		CommentId uint   `primaryKey:"comment_id"`
+		Body      string `db:"body"`
+	}
+	type author struct {
+		AuthorId uint   `primaryKey:"author_id"`
+		Name     string `db:"author_name"`
+	}
+	type post struct {
+		PostId   uint      `primaryKey:"post_id"`
+		Comments []comment `relationship:"oneToMany"`
+		Author   *author   `relationship:"oneToOne"`
+	}
+	// body and author_name are selected with COALESCE, so they are not NULL for unmatched rows
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM posts$",
+		[][]interface{}{
+			{1, 10, "first", 100, "John"},
+			{2, nil, "", nil, "anonymous"},
+		},
+		[]string{"post_id", "comment_id", "body", "author_id", "author_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM posts")
+	assert.NoError(t, err)
+
+	var result []post
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []post{
+		{PostId: 1, Comments: []comment{{CommentId: 10, Body: "first"}}, Author: &author{AuthorId: 100, Name: "John"}},
+		{PostId: 2},
+	}, result)
+}
+
+func TestScanManyWithRelationshipPrefixes(t *testing.T) {
+	type address struct {
+		AddressId uint   `primaryKey:"id"`
+		City      string `db:"city"`
+	}
+	type person struct {
+		PersonId uint     `primaryKey:"id"`
+		Name     string   `db:"name"`
+		Address  *address `relationship:"oneToOne" prefix:"address_"`
+	}
+	type project struct {
+		ProjectId uint           `primaryKey:"id"`
+		Name      string         `db:"name"`
+		Owner     person         `relationship:"oneToOne" prefix:"owner_"`
+		Members   []person       `relationship:"oneToMany" prefix:"member_"`
+		Extra     map[string]any `db:",extra"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM projects$",
+		[][]interface{}{
+			{1, "apollo", 10, "John", 100, "Tallinn", 11, "Jane", 5},
+			{2, "gemini", 12, "Mark", 101, "Tartu", 10, "John", 3},
+		},
+		[]string{"id", "name", "owner_id", "owner_name", "owner_address_id", "owner_address_city", "member_id", "member_name", "tasks"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM projects")
+	assert.NoError(t, err)
+
+	var result []project
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []project{
+		{
+			ProjectId: 1,
+			Name:      "apollo",
+			Owner:     person{PersonId: 10, Name: "John", Address: &address{AddressId: 100, City: "Tallinn"}},
+			Members:   []person{{PersonId: 11, Name: "Jane"}},
+			Extra:     map[string]any{"tasks": 5},
+		},
+		{
+			ProjectId: 2,
+			Name:      "gemini",
+			Owner:     person{PersonId: 12, Name: "Mark", Address: &address{AddressId: 101, City: "Tartu"}},
+			// member columns do not select address, which John has as the owner of another project
+			Members: []person{{PersonId: 10, Name: "John"}},
+			Extra:   map[string]any{"tasks": 3},
+		},
+	}, result)
+}
+
+func TestScanOneWithStrictNulls(t *testing.T) {
+	type user struct {
+		UserId   uint        `primaryKey:"user_id"`
+		Nickname *string     `db:"nickname"`
+		Phone    pgtype.Text `db:"phone"`
+		Age      int         `db:"age"`
+	}
+	StrictNulls = true
+	defer func() { StrictNulls = false }()
+	setupFn := func(age any) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+			[][]interface{}{{1, nil, nil, age}}, []string{"user_id", "nickname", "phone", "age"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var nullableResult user
+	err := ScanOne(setupFn(30), &nullableResult)
+	assert.NoError(t, err)
+	assert.Equal(t, user{UserId: 1, Age: 30}, nullableResult)
+
+	var strictResult user
+	err = ScanOne(setupFn(nil), &strictResult)
+	assert.EqualError(t, err, "column age is NULL, but int field cannot hold NULL")
+}
+
+func TestScanOneWithSqlNullTypes(t *testing.T) {
+	type user struct {
+		UserId    uint            `primaryKey:"user_id"`
+		Nickname  sql.NullString  `db:"nickname"`
+		Age       sql.NullInt64   `db:"age"`
+		Active    sql.NullBool    `db:"active"`
+		Score     sql.NullFloat64 `db:"score"`
+		LastLogin sql.NullTime    `db:"last_login"`
+	}
+	lastLogin := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+		[][]interface{}{{1, "neo", int32(30), nil, 4.5, lastLogin}},
+		[]string{"user_id", "nickname", "age", "active", "score", "last_login"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+	assert.NoError(t, err)
+
+	result := user{Active: sql.NullBool{Bool: true, Valid: true}}
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, sql.NullString{String: "neo", Valid: true}, result.Nickname)
+	assert.Equal(t, sql.NullInt64{Int64: 30, Valid: true}, result.Age)
+	assert.Equal(t, sql.NullBool{}, result.Active)
+	assert.Equal(t, sql.NullFloat64{Float64: 4.5, Valid: true}, result.Score)
+	assert.Equal(t, sql.NullTime{Time: lastLogin, Valid: true}, result.LastLogin)
+}
+
+func TestScanOneWithArrayColumns(t *testing.T) {
+	type article struct {
+		ArticleId uint        `primaryKey:"article_id"`
+		Tags      []string    `db:"tags"`
+		Scores    []int       `db:"scores"`
+		Ratings   []float64   `db:"ratings"`
+		Reviewers []*string   `db:"reviewers"`
+		Published []time.Time `db:"published"`
+	}
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM articles$",
+		[][]interface{}{{
+			1,
+			[]string{"go", "postgres"},
+			[]int32{5, 4},
+			[]interface{}{float64(4.5), int64(3)},
+			[]interface{}{"john", nil},
+			[]interface{}{published},
+		}},
+		[]string{"article_id", "tags", "scores", "ratings", "reviewers", "published"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM articles")
+	assert.NoError(t, err)
+
+	var result article
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go", "postgres"}, result.Tags)
+	assert.Equal(t, []int{5, 4}, result.Scores)
+	assert.Equal(t, []float64{4.5, 3}, result.Ratings)
+	assert.Len(t, result.Reviewers, 2)
+	assert.Equal(t, "john", *result.Reviewers[0])
+	assert.Nil(t, result.Reviewers[1])
+	assert.Equal(t, []time.Time{published}, result.Published)
+}
+
+func TestScanOneWithArrayColumnOfWrongType(t *testing.T) {
+	type article struct {
+		ArticleId uint  `primaryKey:"article_id"`
+		Scores    []int `db:"scores"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM articles$",
+		[][]interface{}{{1, []interface{}{int32(1), "two"}}},
+		[]string{"article_id", "scores"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM articles")
+	assert.NoError(t, err)
+
+	var result article
+	err = ScanOne(rows, &result)
+
+	assert.EqualError(t, err, "failed to map column scores: array element 1: type mismatch: expected int64, got string")
+}
+
+func TestScanOneWithNarrowUnsignedSource(t *testing.T) {
+	type relation struct {
+		RelationId uint   `primaryKey:"relation_id"`
+		Oid        uint   `db:"oid"`
+		Flags      uint64 `db:"flags"`
+		Kind       uint16 `db:"kind"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM relations$",
+		[][]interface{}{{1, uint32(16384), uint16(7), uint8(3)}}, []string{"relation_id", "oid", "flags", "kind"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM relations")
+	assert.NoError(t, err)
+
+	var result relation
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, relation{RelationId: 1, Oid: 16384, Flags: 7, Kind: 3}, result)
+}
+
+func TestScanOneWithCoerceStringNumbers(t *testing.T) {
+	type legacyAccount struct {
+		AccountId uint    `primaryKey:"account_id"`
+		Balance   int     `db:"balance"`
+		Limit     uint    `db:"credit_limit"`
+		Rate      float64 `db:"rate"`
+		Code      string  `db:"code"`
+	}
+	setupFn := func() pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM accounts$",
+			[][]interface{}{{1, "-120", "500", "0.25", 4711}}, []string{"account_id", "balance", "credit_limit", "rate", "code"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM accounts")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var result legacyAccount
+	err := ScanOne(setupFn(), &result)
+	assert.ErrorContains(t, err, "type mismatch")
+
+	CoerceStringNumbers = true
+	defer func() { CoerceStringNumbers = false }()
+
+	result = legacyAccount{}
+	err = ScanOne(setupFn(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, legacyAccount{AccountId: 1, Balance: -120, Limit: 500, Rate: 0.25, Code: "4711"}, result)
+
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM accounts$",
+		[][]interface{}{{1, "lots", "500", "0.25", 4711}}, []string{"account_id", "balance", "credit_limit", "rate", "code"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM accounts")
+	assert.NoError(t, err)
+	err = ScanOne(rows, &result)
+	assert.ErrorContains(t, err, `cannot coerce "lots" into int field`)
+}
+
+func TestScanManyWithStrictColumns(t *testing.T) {
+	type address struct {
+		AddressId uint   `primaryKey:"address_id"`
+		Street    string `db:"address_street"`
+		Owner     *user  `relationship:"oneToOne"`
+	}
+	StrictColumns = true
+	defer func() { StrictColumns = false }()
+	setupFn := func(columns []string) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM address$",
+			[][]interface{}{{1, "Main street", 1, "John"}}, columns)
+		rows, err := mock.Query(context.Background(), "SELECT * FROM address")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var result []address
+	err := ScanMany(setupFn([]string{"address_id", "streeet", "user_id", "name"}), &result)
+	assert.EqualError(t, err, "columns name, streeet are not mapped to any field of entity(mapper.address)")
+
+	err = ScanMany(setupFn([]string{"address_id", "address_street", "user_id", "user_name"}), &result)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+}
+
+func TestScanManyWithRequireAllFields(t *testing.T) {
+	type address struct {
+		AddressId uint   `primaryKey:"address_id"`
+		Street    string `db:"address_street"`
+		Zip       string `db:"zip"`
+		Owner     *user  `relationship:"oneToOne"`
+	}
+	RequireAllFields = true
+	defer func() { RequireAllFields = false }()
+
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM address$",
+		[][]interface{}{{1, "Main street", 1}}, []string{"address_id", "address_street", "user_id"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM address")
+	assert.NoError(t, err)
+
+	var result []address
+	err = ScanMany(rows, &result)
+	assert.EqualError(t, err, "columns zip of entity(mapper.address) are missing from the result")
+
+	mock = setupPostgresMock(t, "^SELECT (.+) FROM address$",
+		[][]interface{}{{1, "Main street", nil, 1}}, []string{"address_id", "address_street", "zip", "user_id"})
+	rows, err = mock.Query(context.Background(), "SELECT * FROM address")
+	assert.NoError(t, err)
+
+	err = ScanMany(rows, &result)
+	assert.EqualError(t, err, "columns user_name of entity(mapper.user) are missing from the result")
+}
+
+func TestScanOneWithUnixTimeFormat(t *testing.T) {
+	type event struct {
+		EventId    uint       `primaryKey:"event_id"`
+		CreatedAt  time.Time  `db:"created_at" format:"unix"`
+		ReceivedAt *time.Time `db:"received_at" format:"unixmilli"`
+		Name       string     `db:"name" format:"unix"`
+	}
+	setupFn := func(columns []string, values []interface{}) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM events$", [][]interface{}{values}, columns)
+		rows, err := mock.Query(context.Background(), "SELECT * FROM events")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var result event
+	err := ScanOne(setupFn([]string{"event_id", "created_at", "received_at"}, []interface{}{1, int64(1704164645), int64(1704164645123)}), &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), result.CreatedAt)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC), *result.ReceivedAt)
+
+	err = ScanOne(setupFn([]string{"event_id", "created_at"}, []interface{}{1, "yesterday"}), &result)
+	assert.ErrorContains(t, err, "type mismatch: expected integer unix time, got string")
+
+	err = ScanOne(setupFn([]string{"event_id", "name"}, []interface{}{1, int64(1)}), &result)
+	assert.ErrorContains(t, err, "format unix is supported only for time.Time fields, got string")
+}
+
+func TestScanOneWithTextTime(t *testing.T) {
+	type event struct {
+		EventId   uint      `primaryKey:"event_id"`
+		CreatedAt time.Time `db:"created_at"`
+		Day       time.Time `db:"day" format:"02.01.2006"`
+	}
+	setupFn := func(values []interface{}) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM events$", [][]interface{}{values}, []string{"event_id", "created_at", "day"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM events")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var result event
+	err := ScanOne(setupFn([]interface{}{1, "2024-01-02T03:04:05.5Z", "24.12.2024"}), &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 500000000, time.UTC), result.CreatedAt.UTC())
+	assert.Equal(t, time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC), result.Day)
+
+	err = ScanOne(setupFn([]interface{}{1, "2024-01-02", "24.12.2024"}), &result)
+	assert.ErrorContains(t, err, "failed to map column created_at")
+}
+
+type money struct {
+	Cents    int64
+	Currency string
+}
+
+func TestScanOneWithRegisteredConverter(t *testing.T) {
+	type invoice struct {
+		InvoiceId uint   `primaryKey:"invoice_id"`
+		Total     money  `db:"total"`
+		Discount  *money `db:"discount"`
+	}
+	RegisterConverter(reflect.TypeOf(money{}), func(dst reflect.Value, src any) error {
+		text, ok := src.(string)
+		if !ok {
+			return fmt.Errorf("expected money text, got %T", src)
+		}
+		var euros, cents int64
+		if _, err := fmt.Sscanf(text, "%d.%d EUR", &euros, &cents); err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(money{Cents: euros*100 + cents, Currency: "EUR"}))
+		return nil
+	})
+	defer converters.Delete(reflect.TypeOf(money{}))
+
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM invoices$",
+		[][]interface{}{{1, "12.50 EUR", "1.05 EUR"}}, []string{"invoice_id", "total", "discount"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM invoices")
+	assert.NoError(t, err)
+
+	var result invoice
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, money{Cents: 1250, Currency: "EUR"}, result.Total)
+	assert.Equal(t, &money{Cents: 105, Currency: "EUR"}, result.Discount)
+}
+
+func TestScanOneWithPgtypeWrappers(t *testing.T) {
+	type order struct {
+		OrderId   uint        `primaryKey:"order_id"`
+		CreatedAt time.Time   `db:"created_at"`
+		ShippedAt *time.Time  `db:"shipped_at"`
+		Quantity  int         `db:"quantity"`
+		Price     float64     `db:"price"`
+		Note      string      `db:"note"`
+		Paid      bool        `db:"paid"`
+		Comment   pgtype.Text `db:"comment"`
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+		[][]interface{}{{1, pgtype.Timestamptz{Time: createdAt, Valid: true}, pgtype.Timestamptz{}, pgtype.Int4{Int32: 3, Valid: true},
+			pgtype.Numeric{Int: big.NewInt(995), Exp: -2, Valid: true}, pgtype.Text{String: "fragile", Valid: true},
+			pgtype.Bool{Bool: true, Valid: true}, pgtype.Text{String: "thanks", Valid: true}}},
+		[]string{"order_id", "created_at", "shipped_at", "quantity", "price", "note", "paid", "comment"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+	assert.NoError(t, err)
+
+	var result order
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, order{OrderId: 1, CreatedAt: createdAt, Quantity: 3, Price: 9.95, Note: "fragile", Paid: true,
+		Comment: pgtype.Text{String: "thanks", Valid: true}}, result)
+}
+
+func TestScanManyCap(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+		[][]interface{}{{1, "John"}, {2, "Mary"}, {1, "John"}}, []string{"user_id", "user_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+	assert.NoError(t, err)
+
+	var result []user
+	err = ScanManyCap(rows, &result, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []user{{UserId: 1, Name: "John"}, {UserId: 2, Name: "Mary"}}, result)
+	assert.Equal(t, 10, cap(result))
+}
+
+func TestScanEach(t *testing.T) {
+	setupFn := func() pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+			[][]interface{}{{1, "John"}, {2, "Mary"}, {3, "Jane"}}, []string{"user_id", "user_name"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var names []string
+	err := ScanEach(setupFn(), reflect.TypeOf(user{}), func(v any) error {
+		names = append(names, v.(*user).Name)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"John", "Mary", "Jane"}, names)
+
+	stop := errors.New("stop")
+	names = nil
+	err = ScanEach(setupFn(), reflect.TypeOf(&user{}), func(v any) error {
+		names = append(names, v.(*user).Name)
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, []string{"John"}, names)
+}
+
+func TestScanEachChecksColumnsBeforeRows(t *testing.T) {
+	defer func() { StrictColumns, RequireAllFields = false, false }()
+	setupFn := func(columns []string) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM users$", [][]interface{}{}, columns)
+		rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+		assert.NoError(t, err)
+		return rows
+	}
+	fn := func(v any) error { return nil }
+
+	StrictColumns = true
+	err := ScanEach(setupFn([]string{"user_id", "user_name", "nickname"}), reflect.TypeOf(user{}), fn)
+	assert.EqualError(t, err, "columns nickname are not mapped to any field of entity(mapper.user)")
+
+	StrictColumns, RequireAllFields = false, true
+	err = ScanEach(setupFn([]string{"user_id"}), reflect.TypeOf(user{}), fn)
+	assert.EqualError(t, err, "columns user_name of entity(mapper.user) are missing from the result")
+}
+
+func TestScanManyAppend(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM users$",
+		[][]interface{}{{2, "Mary"}, {3, "Jane"}}, []string{"user_id", "user_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM users")
+	assert.NoError(t, err)
+
+	result := []user{{UserId: 1, Name: "John"}}
+	err = ScanManyAppend(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []user{{UserId: 1, Name: "John"}, {UserId: 2, Name: "Mary"}, {UserId: 3, Name: "Jane"}}, result)
+
+	var single user
+	err = ScanManyAppend(rows, &single)
+	assert.EqualError(t, err, "dest must be a slice")
+}
+
+func TestAnalyzeDetectsAmbiguousColumns(t *testing.T) {
+	type author struct {
+		AuthorId uint   `primaryKey:"id"`
+		Name     string `db:"name"`
+	}
+	type book struct {
+		BookId uint    `primaryKey:"id"`
+		Title  string  `db:"title"`
+		Author *author `relationship:"oneToOne"`
+	}
+	type prefixedBook struct {
+		BookId uint    `primaryKey:"id"`
+		Title  string  `db:"title"`
+		Author *author `relationship:"oneToOne" prefix:"author_"`
+	}
+
+	err := RegisterEntity(reflect.TypeOf(book{}))
+	assert.EqualError(t, err, "column id is mapped by both mapper.book and mapper.author, use prefix tag on the relationship to tell them apart")
+	_, exists := GetEntityGraphMappingInfo(reflect.TypeOf(book{}))
+	assert.False(t, exists)
+
+	assert.NoError(t, RegisterEntity(reflect.TypeOf(prefixedBook{})))
+}
+
+func TestScanOneWithMultipleOneToOneOfSameType(t *testing.T) {
+	type document struct {
+		DocumentId uint   `primaryKey:"document_id"`
+		Title      string `db:"title"`
+		CreatedBy  *user  `relationship:"oneToOne" prefix:"created_by_"`
+		UpdatedBy  *user  `relationship:"oneToOne" prefix:"updated_by_"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+		[][]interface{}{{1, "Report", 1, "John", 2, "Mary"}},
+		[]string{"document_id", "title", "created_by_user_id", "created_by_user_name", "updated_by_user_id", "updated_by_user_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+	assert.NoError(t, err)
+
+	var result document
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, document{
+		DocumentId: 1,
+		Title:      "Report",
+		CreatedBy:  &user{UserId: 1, Name: "John"},
+		UpdatedBy:  &user{UserId: 2, Name: "Mary"},
+	}, result)
+
+	type unprefixedDocument struct {
+		DocumentId uint  `primaryKey:"document_id"`
+		CreatedBy  *user `relationship:"oneToOne"`
+		UpdatedBy  *user `relationship:"oneToOne"`
+	}
+	err = RegisterEntity(reflect.TypeOf(unprefixedDocument{}))
+	assert.EqualError(t, err, "relationships CreatedBy and UpdatedBy of entity(mapper.unprefixedDocument) are mapped from the same columns, use prefix tag to tell them apart")
+}
+
+func TestScanManyWithPrimaryKeyColumnFromDbTag(t *testing.T) {
+	type userView struct {
+		UserId uint   `primaryKey:"id" db:"u_id"`
+		Name   string `db:"u_name"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM user_view$",
+		[][]interface{}{{1, "John"}, {1, "John"}, {2, "Mary"}}, []string{"u_id", "u_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM user_view")
+	assert.NoError(t, err)
+
+	var result []userView
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []userView{{UserId: 1, Name: "John"}, {UserId: 2, Name: "Mary"}}, result)
+
+	mappingInfo, _ := GetEntityGraphMappingInfo(reflect.TypeOf(userView{}))
+	assert.Equal(t, []string{"u_id"}, mappingInfo.KeyField.columns())
+}
+
+func TestScanOneWithHstore(t *testing.T) {
+	type product struct {
+		ProductId  uint              `primaryKey:"product_id"`
+		Attributes map[string]string `db:"attributes"`
+		Counts     map[string]int    `db:"counts"`
+	}
+	color, size := "red", "XL"
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM products$",
+		[][]interface{}{{1, pgtype.Hstore{"color": &color, "size": &size, "note": nil}, map[string]any{"views": int64(7)}}},
+		[]string{"product_id", "attributes", "counts"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM products")
+	assert.NoError(t, err)
+
+	var result product
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"color": "red", "size": "XL", "note": ""}, result.Attributes)
+	assert.Equal(t, map[string]int{"views": 7}, result.Counts)
+}
+
+func TestScanManyWithSkipNullKeys(t *testing.T) {
+	type address struct {
+		AddressId uint   `primaryKey:"address_id"`
+		Street    string `db:"address_street"`
+		Residents []user `relationship:"oneToMany"`
+	}
+	setupFn := func() pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM address a RIGHT JOIN users u on u.address_id = a.address_id$",
+			[][]interface{}{{1, "Street", 1, "John"}, {nil, nil, 2, "Homeless"}, {1, "Street", 3, "Jane"}},
+			[]string{"address_id", "address_street", "user_id", "user_name"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM address a RIGHT JOIN users u on u.address_id = a.address_id")
+		assert.NoError(t, err)
+		return rows
+	}
+	SkipNullKeys = true
+	defer func() { SkipNullKeys = false }()
+
+	var result []address
+	err := ScanMany(setupFn(), &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []address{
+		{AddressId: 1, Street: "Street", Residents: []user{{UserId: 1, Name: "John"}, {UserId: 3, Name: "Jane"}}},
+	}, result)
+
+	var first address
+	err = ScanFirst(setupFn(), &first)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), first.AddressId)
+}
+
+func TestScanManyWithRelationshipSharingKey(t *testing.T) {
+	type employee struct {
+		EmployeeId uint   `primaryKey:"employee_id"`
+		Salary     uint   `db:"salary"`
+		Title      string `db:"title"`
+	}
+	type person struct {
+		PersonId uint      `primaryKey:"id"`
+		Name     string    `db:"name"`
+		Employee *employee `relationship:"oneToOne" key:"id"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM person p LEFT JOIN employee e on e.employee_id = p.id$",
+		[][]interface{}{{1, "John", 1000, "Engineer"}, {2, "Jane", 2000, "Manager"}, {3, "Jack", nil, nil}},
+		[]string{"id", "name", "salary", "title"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM person p LEFT JOIN employee e on e.employee_id = p.id")
+	assert.NoError(t, err)
+
+	var result []person
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []person{
+		{PersonId: 1, Name: "John", Employee: &employee{EmployeeId: 1, Salary: 1000, Title: "Engineer"}},
+		{PersonId: 2, Name: "Jane", Employee: &employee{EmployeeId: 2, Salary: 2000, Title: "Manager"}},
+		{PersonId: 3, Name: "Jack"},
+	}, result)
+}
+
+func TestRelationshipSharingKeyRequiresPrimaryKey(t *testing.T) {
+	type detail struct {
+		Note string `db:"note"`
+	}
+	type document struct {
+		DocumentId uint    `primaryKey:"id"`
+		Detail     *detail `relationship:"oneToOne" key:"id"`
+	}
+
+	err := RegisterEntity(reflect.TypeOf(document{}))
+
+	assert.EqualError(t, err, "relationship Detail with key tag requires single column primary key in mapper.detail")
+}
+
+func TestScanWithoutPrimaryKey(t *testing.T) {
+	type event struct {
+		Name string `db:"name"`
+	}
+	setupFn := func() pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM events$", [][]interface{}{{"created"}}, []string{"name"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM events")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var many []event
+	err := ScanMany(setupFn(), &many)
+	assert.EqualError(t, err, "slice element type mapper.event has no primaryKey tag")
+
+	var one event
+	err = ScanOne(setupFn(), &one)
+	assert.EqualError(t, err, "entity(mapper.event) has no primaryKey tag")
+}
+
+type status string
+
+type priority int16
+
+func TestScanOneIntoEnumTypes(t *testing.T) {
+	type task struct {
+		TaskId   uint     `primaryKey:"task_id"`
+		Status   status   `db:"status"`
+		Priority priority `db:"priority"`
+	}
+	setupFn := func(priorityValue any) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM tasks$", [][]interface{}{{1, "active", priorityValue}},
+			[]string{"task_id", "status", "priority"})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM tasks")
+		assert.NoError(t, err)
+		return rows
+	}
+
+	var result task
+	err := ScanOne(setupFn(int32(3)), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, task{TaskId: 1, Status: status("active"), Priority: priority(3)}, result)
+
+	err = ScanOne(setupFn(int32(math.MaxInt32)), &task{})
+	assert.EqualError(t, err, "failed to map column priority: value 2147483647 overflows mapper.priority field")
+
+	err = ScanOne(setupFn(true), &task{})
+	assert.EqualError(t, err, "failed to map column priority: type mismatch: expected int64, got bool for mapper.priority field")
+}
+
+func TestScanManyWithJSONRelationship(t *testing.T) {
+	type orderLine struct {
+		LineId   uint   `primaryKey:"line_id"`
+		Product  string `db:"product"`
+		Quantity int    `db:"quantity"`
+	}
+	type order struct {
+		OrderId uint         `primaryKey:"order_id"`
+		Lines   []orderLine  `relationship:"oneToMany" json:"true"`
+		Latest  *orderLine   `relationship:"oneToOne,json" db:"latest_line"`
+		Extra   []*orderLine `relationship:"oneToMany,json" db:"extra_lines"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+		[][]interface{}{
+			{1, []any{map[string]any{"line_id": float64(1), "product": "Pen", "quantity": float64(2)}},
+				map[string]any{"line_id": float64(1), "product": "Pen", "quantity": float64(2)},
+				`[{"line_id": 3, "product": "Ink", "quantity": 1}]`},
+			{2, []any{nil}, nil, nil},
+		},
+		[]string{"order_id", "lines", "latest_line", "extra_lines"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+	assert.NoError(t, err)
+
+	var result []order
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []order{
+		{OrderId: 1, Lines: []orderLine{{LineId: 1, Product: "Pen", Quantity: 2}},
+			Latest: &orderLine{LineId: 1, Product: "Pen", Quantity: 2},
+			Extra:  []*orderLine{{LineId: 3, Product: "Ink", Quantity: 1}}},
+		{OrderId: 2, Lines: []orderLine{}},
+	}, result)
+}
+
+func TestScanOneWithJSONRelationshipDistinguishesSQLNullFromJSONNull(t *testing.T) {
+	type orderLine struct {
+		LineId uint `primaryKey:"line_id"`
+	}
+	type order struct {
+		OrderId uint        `primaryKey:"id"`
+		Lines   []orderLine `relationship:"oneToMany,json" db:"lines"`
+		Latest  *orderLine  `relationship:"oneToOne,json" db:"latest_line"`
+	}
+	columns := []string{"lines", "latest_line"}
+	previous := func() order {
+		return order{Lines: []orderLine{{LineId: 1}}, Latest: &orderLine{LineId: 1}}
+	}
+
+	sqlNullResult := previous()
+	err := ScanOne(queryNullJSONColumns(t, columns, nil, nil), &sqlNullResult)
+	assert.NoError(t, err)
+	assert.Equal(t, order{OrderId: 1, Lines: []orderLine{{LineId: 1}}, Latest: &orderLine{LineId: 1}}, sqlNullResult)
+
+	jsonNullResult := previous()
+	err = ScanOne(queryNullJSONColumns(t, columns, []byte("\x01null"), []byte("\x01null")), &jsonNullResult)
+	assert.NoError(t, err)
+	assert.Equal(t, order{OrderId: 1}, jsonNullResult)
+
+	textNullResult := previous()
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$", [][]interface{}{{1, "null", "null"}},
+		[]string{"id", "lines", "latest_line"})
+	rows, err := mock.Query(context.Background(), "SELECT id, lines::text, latest_line::text FROM orders")
+	assert.NoError(t, err)
+	err = ScanOne(rows, &textNullResult)
+	assert.NoError(t, err)
+	assert.Equal(t, order{OrderId: 1}, textNullResult)
+}
+
+func TestScanOneWithJSONRelationshipColumnNamedByNamingStrategy(t *testing.T) {
+	type orderLine struct {
+		LineId  uint   `primaryKey:"line_id"`
+		Product string `db:"product"`
+	}
+	type order struct {
+		OrderId    uint        `primaryKey:"order_id"`
+		OrderLines []orderLine `relationship:"oneToMany,json"`
+	}
+	setupFn := func(column string) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+			[][]interface{}{{1, `[{"line_id": 1, "product": "Pen"}]`}},
+			[]string{"order_id", column})
+		rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+		assert.NoError(t, err)
+		return rows
+	}
+	expected := order{OrderId: 1, OrderLines: []orderLine{{LineId: 1, Product: "Pen"}}}
+
+	var result order
+	err := ScanOne(setupFn("order_lines"), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	defaultStrategy := NamingStrategy
+	NamingStrategy = nil
+	InvalidateEntity(reflect.TypeOf(order{}))
+	defer func() {
+		NamingStrategy = defaultStrategy
+		InvalidateEntity(reflect.TypeOf(order{}))
+	}()
+
+	result = order{}
+	err = ScanOne(setupFn("orderlines"), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestScanManyWithGrandchildren(t *testing.T) {
+	type itemTag struct {
+		TagId uint   `primaryKey:"tag_id"`
+		Label string `db:"tag_label"`
+	}
+	type item struct {
+		LineNo uint      `primaryKey:"line_no"`
+		Name   string    `db:"item_name"`
+		Tags   []itemTag `relationship:"oneToMany"`
+	}
+	type order struct {
+		OrderId uint   `primaryKey:"order_id"`
+		Items   []item `relationship:"oneToMany"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM orders$",
+		[][]interface{}{
+			{1, 1, "Pen", 10, "office"},
+			{1, 1, "Pen", 11, "blue"},
+			{1, 2, "Ink", 10, "office"},
+			{2, 1, "Mug", 12, "kitchen"},
+			{2, 1, "Mug", 10, "office"},
+		},
+		[]string{"order_id", "line_no", "item_name", "tag_id", "tag_label"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM orders")
+	assert.NoError(t, err)
+
+	var result []order
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []order{
+		{OrderId: 1, Items: []item{
+			{LineNo: 1, Name: "Pen", Tags: []itemTag{{TagId: 10, Label: "office"}, {TagId: 11, Label: "blue"}}},
+			{LineNo: 2, Name: "Ink", Tags: []itemTag{{TagId: 10, Label: "office"}}},
+		}},
+		{OrderId: 2, Items: []item{
+			{LineNo: 1, Name: "Mug", Tags: []itemTag{{TagId: 12, Label: "kitchen"}, {TagId: 10, Label: "office"}}},
+		}},
+	}, result)
+}
+
+func TestScanManyWithRepeatedOneToOne(t *testing.T) {
+	type team struct {
+		TeamId uint   `primaryKey:"team_id"`
+		Name   string `db:"team_name"`
+	}
+	type role struct {
+		RoleId uint   `primaryKey:"role_id"`
+		Name   string `db:"role_name"`
+	}
+	type member struct {
+		MemberId uint   `primaryKey:"member_id"`
+		Team     team   `relationship:"oneToOne"`
+		Roles    []role `relationship:"oneToMany"`
+	}
+	setupFn := func(rows [][]interface{}) pgx.Rows {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM members$", rows, []string{"member_id", "team_id", "team_name", "role_id", "role_name"})
+		result, err := mock.Query(context.Background(), "SELECT * FROM members")
+		assert.NoError(t, err)
+		return result
+	}
+
+	var result []member
+	err := ScanMany(setupFn([][]interface{}{
+		{1, 10, "core", 100, "admin"},
+		{1, 10, "core", 101, "reviewer"},
+		{2, 10, "core", 100, "admin"},
+	}), &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []member{
+		{MemberId: 1, Team: team{TeamId: 10, Name: "core"}, Roles: []role{{RoleId: 100, Name: "admin"}, {RoleId: 101, Name: "reviewer"}}},
+		{MemberId: 2, Team: team{TeamId: 10, Name: "core"}, Roles: []role{{RoleId: 100, Name: "admin"}}},
+	}, result)
+
+	err = ScanMany(setupFn([][]interface{}{
+		{1, 10, "core", 100, "admin"},
+		{1, 11, "docs", 101, "reviewer"},
+	}), &result)
+	assert.ErrorIs(t, err, ErrTooManyRows)
+}
+
+func TestScanOneIntoDurationFromInterval(t *testing.T) {
+	type job struct {
+		JobId   uint           `primaryKey:"job_id"`
+		Timeout time.Duration  `db:"timeout"`
+		Retry   *time.Duration `db:"retry" format:"interval"`
+		Backoff time.Duration  `db:"backoff" format:"interval"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM jobs$",
+		[][]interface{}{{1, pgtype.Interval{Microseconds: 90_000_000, Days: 1, Valid: true}, int64(1_500_000),
+			pgtype.Interval{Months: 1, Valid: true}}},
+		[]string{"job_id", "timeout", "retry", "backoff"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM jobs")
+	assert.NoError(t, err)
+
+	var result job
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour+90*time.Second, result.Timeout)
+	assert.Equal(t, 1500*time.Millisecond, *result.Retry)
+	assert.Equal(t, 30*24*time.Hour, result.Backoff)
+}
+
+func TestScanOneIntoBytesFields(t *testing.T) {
+	type document struct {
+		DocumentId uint            `primaryKey:"document_id"`
+		Content    []byte          `db:"content"`
+		Raw        json.RawMessage `db:"raw"`
+		Decoded    json.RawMessage `db:"decoded"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM documents$",
+		[][]interface{}{{1, []byte{0x00, 0xff}, []byte(`{"a": [1, 2]}`), map[string]any{"b": true}}},
+		[]string{"document_id", "content", "raw", "decoded"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM documents")
+	assert.NoError(t, err)
+
+	var result document
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0xff}, result.Content)
+	assert.Equal(t, json.RawMessage(`{"a": [1, 2]}`), result.Raw)
+	assert.JSONEq(t, `{"b": true}`, string(result.Decoded))
+}
+
+type employee struct {
+	EmployeeId uint      `primaryKey:"employee_id"`
+	Name       string    `db:"name"`
+	Manager    *employee `relationship:"oneToOne" prefix:"manager_"`
+}
+
+func TestScanManyWithPrefixedSelfReference(t *testing.T) {
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM employees e LEFT JOIN employees m on m.employee_id = e.manager_id$",
+		[][]interface{}{{1, "Boss", nil, nil}, {2, "Worker", 1, "Boss"}},
+		[]string{"employee_id", "name", "manager_employee_id", "manager_name"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM employees e LEFT JOIN employees m on m.employee_id = e.manager_id")
+	assert.NoError(t, err)
+
+	var result []employee
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []employee{
+		{EmployeeId: 1, Name: "Boss"},
+		{EmployeeId: 2, Name: "Worker", Manager: &employee{EmployeeId: 1, Name: "Boss"}},
+	}, result)
+}
+
+func TestScanManyWithPointerChildSlice(t *testing.T) {
+	type review struct {
+		ReviewId uint   `primaryKey:"review_id"`
+		Text     string `db:"review_text"`
+	}
+	type book struct {
+		BookId  uint      `primaryKey:"book_id"`
+		Title   string    `db:"title"`
+		Reviews []*review `relationship:"oneToMany"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM books$",
+		[][]interface{}{{1, "Go", 10, "great"}, {1, "Go", 11, "good"}, {2, "SQL", nil, nil}},
+		[]string{"book_id", "title", "review_id", "review_text"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM books")
+	assert.NoError(t, err)
+
+	var result []book
+	err = ScanMany(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []book{
+		{BookId: 1, Title: "Go", Reviews: []*review{{ReviewId: 10, Text: "great"}, {ReviewId: 11, Text: "good"}}},
+		{BookId: 2, Title: "SQL"},
+	}, result)
+}
@@ -3,17 +3,82 @@ package mapper
 import (
 	"reflect"
 	"sync"
+
+	reflectutils "github.com/raunlo/pgx-with-automapper/reflect_utils"
 )
 
+// PrimaryKeyInfo describes the primary key of an entity, parsed from its `primaryKey` tags
 type PrimaryKeyInfo struct {
-	dbPrimaryKeyName          string
-	structPrimaryKeyFieldName string
+	dbPrimaryKeyName          string   // column of the primary key, first column of a composite primary key
+	structPrimaryKeyFieldName string   // name of the struct field holding the primary key
+	compositeColumns          []string // columns of composite primary key, e.g. `primaryKey:"role_id,composite"`
+}
+
+// columns returns all primary key columns
+func (k *PrimaryKeyInfo) columns() []string {
+	if len(k.compositeColumns) > 0 {
+		return k.compositeColumns
+	}
+	return []string{k.dbPrimaryKeyName}
+}
+
+// isNull reports whether any primary key column of the row is NULL, e.g. when LEFT JOIN found no match
+func (k *PrimaryKeyInfo) isNull(values map[string]any) bool {
+	for _, column := range k.columns() {
+		if value, exists := values[column]; exists && value == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// keyValue returns the primary key of the row. Composite key values are arrays of the key column values, so they can
+// be compared and used as map keys like single column keys.
+func (k *PrimaryKeyInfo) keyValue(values map[string]any) (interface{}, bool) {
+	if len(k.compositeColumns) == 0 {
+		value, exists := values[k.dbPrimaryKeyName]
+		return value, exists
+	}
+
+	key := reflect.New(reflect.ArrayOf(len(k.compositeColumns), reflect.TypeOf((*any)(nil)).Elem())).Elem()
+	for i, column := range k.compositeColumns {
+		value, exists := values[column]
+		if !exists {
+			return nil, false
+		}
+		if value != nil {
+			key.Index(i).Set(reflect.ValueOf(value))
+		}
+	}
+	return key.Interface(), true
+}
+
+// ColumnOptions holds the options given after the column name in a db tag, e.g. `db:"metadata,jsonb"`
+type ColumnOptions struct {
+	jsonb     bool   // decode the column value as JSON into the field
+	generated bool   // value is generated by the database (serial/identity), so it is omitted from inserts
+	join      bool   // join array elements with JoinSeparator into a string field
+	composite bool   // map composite value or array of composites into struct or slice of structs field
+	format    string // format of time stored in other than timestamp column, e.g. `format:"unix"`
 }
 
 type MappingInfo struct {
-	KeyField      *PrimaryKeyInfo      // Primary key field
-	FieldMapping  map[string]int       // Maps db column name -> struct field index
-	Relationships map[int]reflect.Type // Maps struct field index -> relationship struct type
+	KeyField      *PrimaryKeyInfo          // Primary key field, nil when the entity has no primary key
+	FieldMapping  map[string][]int         // Maps db column name -> struct field index path
+	ColumnOptions map[string]ColumnOptions // Maps db column name -> options parsed from the db tag
+	Relationships map[int]reflect.Type     // Maps struct field index -> relationship struct type
+	// Maps struct field index -> column prefix of the relationship, e.g. `relationship:"oneToOne" prefix:"owner_"`
+	RelationshipPrefixes map[int]string
+	// Maps struct field index -> column holding primary key of the relationship, e.g. `relationship:"oneToOne" key:"id"`
+	// for extension tables sharing primary key of the parent
+	RelationshipKeys map[int]string
+	// Maps struct field index -> column holding the relationship as JSON, e.g. json_agg of children tagged with
+	// `relationship:"oneToMany" json:"true"` or `relationship:"oneToMany,json"`
+	JSONRelationships map[int]string
+	ExtraField        *int // Index of the map field tagged `db:",extra"` receiving unmapped columns
+	// Columns mapped anywhere in the entity graph, prefixed with column prefixes of the relationships. Collected once
+	// the entity graph is analyzed.
+	GraphColumns map[string]struct{}
 }
 
 var (
@@ -31,3 +96,22 @@ func GetEntityGraphMappingInfo(key reflect.Type) (*MappingInfo, bool) {
 func SetEntityGraphMappingInfo(key reflect.Type, value *MappingInfo) {
 	globalEntityGraphMappingInfo.Store(key, value)
 }
+
+// InvalidateEntity removes cached mapping info of the entity, so that it is analyzed again on next use. It waits for
+// running analysis to finish, so that entities being analyzed are not removed midway.
+func InvalidateEntity(key reflect.Type) {
+	if key == nil {
+		return
+	}
+	analysisMutex.Lock()
+	defer analysisMutex.Unlock()
+	globalEntityGraphMappingInfo.Delete(reflectutils.DeReferencePointer(key))
+}
+
+// ResetEntityGraphCache removes cached mapping info of all entities. It waits for running analysis to finish like
+// InvalidateEntity does.
+func ResetEntityGraphCache() {
+	analysisMutex.Lock()
+	defer analysisMutex.Unlock()
+	globalEntityGraphMappingInfo.Clear()
+}
@@ -1,9 +1,11 @@
 package mapper
 
 import (
+	"context"
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -53,7 +55,7 @@ func TestSetAndGetEntityGraphMappingInfo(t *testing.T) {
 			dbPrimaryKeyName:          "id",
 			structPrimaryKeyFieldName: "id",
 		},
-		FieldMapping: map[string]int{"id": 0},
+		FieldMapping: map[string][]int{"id": {0}},
 	}
 
 	// Store the mapping info
@@ -77,3 +79,160 @@ func TestGetEntityGraphMappingInfoWithNonExistentKey(t *testing.T) {
 	assert.False(t, exists)
 	assert.Nil(t, result)
 }
+
+func TestCompositePrimaryKeyValue(t *testing.T) {
+	keyField := &PrimaryKeyInfo{dbPrimaryKeyName: "role_id", compositeColumns: []string{"role_id", "user_id"}}
+
+	first, firstExists := keyField.keyValue(map[string]any{"role_id": 1, "user_id": 10, "name": "John"})
+	second, _ := keyField.keyValue(map[string]any{"role_id": 1, "user_id": 10, "name": "Jane"})
+	other, _ := keyField.keyValue(map[string]any{"role_id": 10, "user_id": 1})
+	_, missingExists := keyField.keyValue(map[string]any{"role_id": 1})
+
+	assert.True(t, firstExists)
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+	assert.False(t, missingExists)
+	assert.Equal(t, []string{"role_id", "user_id"}, keyField.columns())
+}
+
+func TestInvalidateEntityAndResetEntityGraphCache(t *testing.T) {
+	type first struct {
+		FirstId uint `primaryKey:"first_id"`
+	}
+	type second struct {
+		SecondId uint `primaryKey:"second_id"`
+	}
+	assert.NoError(t, RegisterEntity(reflect.TypeOf(first{})))
+	assert.NoError(t, RegisterEntity(reflect.TypeOf(second{})))
+
+	InvalidateEntity(reflect.TypeOf(&first{}))
+	_, exists := GetEntityGraphMappingInfo(reflect.TypeOf(first{}))
+	assert.False(t, exists)
+	_, exists = GetEntityGraphMappingInfo(reflect.TypeOf(second{}))
+	assert.True(t, exists)
+
+	ResetEntityGraphCache()
+	_, exists = GetEntityGraphMappingInfo(reflect.TypeOf(second{}))
+	assert.False(t, exists)
+}
+
+func TestConcurrentFirstTimeAnalysis(t *testing.T) {
+	type answer struct {
+		AnswerId uint `primaryKey:"answer_id"`
+	}
+	type question struct {
+		QuestionId uint      `primaryKey:"question_id"`
+		Answers    []answer  `relationship:"oneToMany"`
+		Related    *question `relationship:"oneToOne"`
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mappingInfo, err := getMappingInfo(reflect.TypeOf(question{}))
+			if err == nil && mappingInfo == nil {
+				err = assert.AnError
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestInvalidationWaitsForRunningAnalysis(t *testing.T) {
+	type pending struct {
+		PendingId uint `primaryKey:"pending_id"`
+	}
+	pendingType := reflect.TypeOf(pending{})
+
+	// simulate analysis in progress, which holds the lock and a nil placeholder breaking recursion
+	analysisMutex.Lock()
+	SetEntityGraphMappingInfo(pendingType, nil)
+	invalidated := make(chan struct{})
+	go func() {
+		InvalidateEntity(pendingType)
+		ResetEntityGraphCache()
+		close(invalidated)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	_, placeholderExists := GetEntityGraphMappingInfo(pendingType)
+	analysisMutex.Unlock()
+	<-invalidated
+
+	assert.True(t, placeholderExists)
+	_, exists := GetEntityGraphMappingInfo(pendingType)
+	assert.False(t, exists)
+}
+
+func TestInvalidationConcurrentWithScanMany(t *testing.T) {
+	type reply struct {
+		ReplyId uint   `primaryKey:"reply_id"`
+		Text    string `db:"reply_text"`
+	}
+	type thread struct {
+		ThreadId uint    `primaryKey:"thread_id"`
+		Parent   *thread `relationship:"oneToOne" prefix:"parent_"`
+		Replies  []reply `relationship:"oneToMany"`
+	}
+	columns := []string{"thread_id", "parent_thread_id", "reply_id", "reply_text"}
+	var rows [][]interface{}
+	expected := []thread{{ThreadId: 2, Parent: &thread{ThreadId: 1}}}
+	for replyId := uint(1); replyId <= 100; replyId++ {
+		rows = append(rows, []interface{}{2, 1, replyId, "reply"})
+		expected[0].Replies = append(expected[0].Replies, reply{ReplyId: replyId, Text: "reply"})
+	}
+
+	done := make(chan struct{})
+	var invalidation sync.WaitGroup
+	invalidation.Add(1)
+	go func() {
+		defer invalidation.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				InvalidateEntity(reflect.TypeOf(thread{}))
+			} else {
+				ResetEntityGraphCache()
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				mock := setupPostgresMock(t, "^SELECT (.+) FROM threads$", rows, columns)
+				result, err := mock.Query(context.Background(), "SELECT * FROM threads")
+				assert.NoError(t, err)
+
+				var threads []thread
+				assert.NoError(t, ScanMany(result, &threads))
+				assert.Equal(t, expected, threads)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	invalidation.Wait()
+}
+
+func TestAnalyzedKeyField(t *testing.T) {
+	mappingInfo, err := getMappingInfo(reflect.TypeOf(user{}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, &PrimaryKeyInfo{dbPrimaryKeyName: "user_id", structPrimaryKeyFieldName: "UserId"}, mappingInfo.KeyField)
+}
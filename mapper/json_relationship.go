@@ -0,0 +1,168 @@
+package mapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	reflectutils "github.com/raunlo/pgx-with-automapper/reflect_utils"
+)
+
+// analyzeJSONRelationship registers relationship tagged with `json:"true"` or `relationship:"oneToMany,json"`, which is
+// read from a single column holding the related entities as JSON, e.g. json_agg(row_to_json(c)) of the children. The
+// relationship tag option does not clash with encoding/json, which would name the field "true". Column is named by db
+// tag of the field and defaults to the name derived by NamingStrategy, or to the lower-cased field name, like
+// PostgreSQL folds unquoted aliases, when NamingStrategy is nil.
+func analyzeJSONRelationship(field reflect.StructField, index int, mappingInfo *MappingInfo) error {
+	elementType := reflectutils.DeReferencePointer(field.Type)
+	if elementType.Kind() == reflect.Slice {
+		elementType = reflectutils.DeReferencePointer(elementType.Elem())
+	}
+	if elementType.Kind() != reflect.Struct {
+		return errors.New(fmt.Sprintf("json relationship %s must be a struct or a slice of structs", field.Name))
+	}
+	if err := analyzeEntity(elementType); err != nil {
+		return err
+	}
+
+	columnName, _ := parseTag(field.Tag.Get("db"))
+	if columnName == "" && NamingStrategy != nil {
+		columnName = NamingStrategy(field.Name)
+	} else if columnName == "" {
+		columnName = strings.ToLower(field.Name)
+	}
+	mappingInfo.JSONRelationships[index] = columnName
+	return nil
+}
+
+// setJSONRelationships decodes JSON relationship columns of the row into their fields. JSON objects are mapped by db
+// tags of the related entity like rows are. JSON null clears the field, while SQL NULL leaves it untouched.
+func setJSONRelationships(entityMappingInfo *MappingInfo, objValue reflect.Value, values map[string]any) error {
+	for fieldIndex, columnName := range entityMappingInfo.JSONRelationships {
+		value := values[columnName]
+		if value == nil {
+			continue
+		}
+		if _, isJSONNull := value.(jsonNull); isJSONNull {
+			field := objValue.Field(fieldIndex)
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		decoded, err := decodeJSONValue(value)
+		if err != nil {
+			return fmt.Errorf("failed to map column %s: %w", columnName, err)
+		}
+		if err := setJSONRelationship(objValue.Field(fieldIndex), decoded); err != nil {
+			return fmt.Errorf("failed to map column %s: %w", columnName, err)
+		}
+	}
+	return nil
+}
+
+func setJSONRelationship(field reflect.Value, decoded any) error {
+	if decoded == nil {
+		field.Set(reflect.Zero(field.Type())) // JSON null read as text
+		return nil
+	}
+	if field.Kind() != reflect.Slice {
+		entity, err := jsonEntity(reflectutils.DeReferencePointer(field.Type()), decoded)
+		if err != nil {
+			return err
+		}
+		if field.Kind() == reflect.Ptr {
+			field.Set(entity)
+		} else {
+			field.Set(entity.Elem())
+		}
+		return nil
+	}
+
+	elements, ok := decoded.([]any)
+	if !ok {
+		return fmt.Errorf("expected JSON array, got %T", decoded)
+	}
+	elementType := field.Type().Elem()
+	slice := reflect.MakeSlice(field.Type(), 0, len(elements))
+	for i, element := range elements {
+		if element == nil {
+			continue // json_agg of LEFT JOIN without matches is [null]
+		}
+		entity, err := jsonEntity(reflectutils.DeReferencePointer(elementType), element)
+		if err != nil {
+			return fmt.Errorf("array element %d: %w", i, err)
+		}
+		if elementType.Kind() == reflect.Ptr {
+			slice = reflect.Append(slice, entity)
+		} else {
+			slice = reflect.Append(slice, entity.Elem())
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// jsonEntity maps decoded JSON object into a new entity and returns pointer to it
+func jsonEntity(entityType reflect.Type, decoded any) (reflect.Value, error) {
+	object, ok := decoded.(map[string]any)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("expected JSON object, got %T", decoded)
+	}
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	entity := reflect.New(entityType)
+	if err := setEntityColumns(entityMappingInfo, entity.Elem(), object); err != nil {
+		return reflect.Value{}, err
+	}
+	return entity, nil
+}
+
+// decodeJSONValue decodes JSON column value, which pgx returns as decoded value of json and jsonb columns or as text
+// otherwise. Numbers are decoded into int64 when they are integers, so they fit integer fields of any size.
+func decodeJSONValue(value any) (any, error) {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %T as JSON: %w", value, err)
+		}
+		data = encoded
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return normalizeJSONNumbers(decoded), nil
+}
+
+func normalizeJSONNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if intValue, err := v.Int64(); err == nil {
+			return intValue
+		}
+		floatValue, _ := v.Float64()
+		return floatValue
+	case map[string]any:
+		for key, element := range v {
+			v[key] = normalizeJSONNumbers(element)
+		}
+	case []any:
+		for i, element := range v {
+			v[i] = normalizeJSONNumbers(element)
+		}
+	}
+	return value
+}
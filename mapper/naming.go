@@ -0,0 +1,29 @@
+package mapper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives the column name for exported struct fields that have no db, primaryKey or relationship tag.
+// Set it to nil to map only tagged fields. Mapping info is cached per type, so it must be set before entities are
+// scanned for the first time.
+var NamingStrategy func(fieldName string) string = SnakeCase
+
+// SnakeCase converts Go field name into snake_case column name, e.g. CreatedAt -> created_at and UserID -> user_id
+func SnakeCase(fieldName string) string {
+	runes := []rune(fieldName)
+	var builder strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				builder.WriteRune('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,22 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"Name":        "name",
+		"CreatedAt":   "created_at",
+		"UserID":      "user_id",
+		"HTTPServer":  "http_server",
+		"Address2":    "address2",
+		"already_set": "already_set",
+	}
+
+	for fieldName, expected := range cases {
+		assert.Equal(t, expected, SnakeCase(fieldName), fieldName)
+	}
+}
@@ -0,0 +1,73 @@
+package mapper
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Package level options changing how query results are mapped. Options are read while scanning, so they should be
+// set once during initialization and not changed while queries are running.
+var (
+	// TimeLocation converts scanned time.Time values into given location, e.g. the timezone of the user. Zero time
+	// values are left as is. Times are kept in the location pgx returns them in when TimeLocation is nil.
+	TimeLocation *time.Location
+
+	// StrictNulls makes scanning fail when NULL column is mapped into field which cannot hold NULL, e.g. int or string
+	// instead of *int or sql.NullString. By default such fields are left with their zero values.
+	StrictNulls bool
+
+	// SkipNullKeys skips rows where primary key of the top-level entity is NULL, e.g. rows of RIGHT or FULL OUTER JOIN
+	// without the entity. By default such rows are mapped into an entity with NULL key.
+	SkipNullKeys bool
+
+	// StrictColumns makes scanning fail when the result has columns which are not mapped to any field of the entity
+	// graph, e.g. because of a typo in column alias. By default such columns are ignored.
+	StrictColumns bool
+
+	// RequireAllFields makes scanning fail when a column mapped to a field of an entity is not selected at all, e.g.
+	// because SELECT list forgot it. NULL values are still allowed. By default such fields are left unset.
+	RequireAllFields bool
+
+	// CoerceStringNumbers allows mapping text columns into int, uint and float fields by parsing them, and numeric
+	// columns into string fields by formatting them. By default such values are rejected as type mismatch.
+	CoerceStringNumbers bool
+
+	// JoinSeparator separates array elements scanned into string fields tagged with join option, e.g. `db:"tags,join"`
+	JoinSeparator = ","
+)
+
+var (
+	columnTransforms = sync.Map{}
+	converters       = sync.Map{}
+)
+
+// RegisterConverter registers function setting non-NULL database values into fields of given type, e.g. domain types
+// like money. Converters take precedence over the built-in conversions.
+func RegisterConverter(t reflect.Type, converter func(dst reflect.Value, src any) error) {
+	converters.Store(t, converter)
+}
+
+// getConverter returns converter registered for the type
+func getConverter(t reflect.Type) (func(dst reflect.Value, src any) error, bool) {
+	converter, exists := converters.Load(t)
+	if !exists {
+		return nil, false
+	}
+	return converter.(func(dst reflect.Value, src any) error), true
+}
+
+// RegisterColumnTransform registers transformation applied to non-NULL values of the column before they are set into
+// entity fields, e.g. lowercasing emails. Transforms apply to the column in all entities.
+func RegisterColumnTransform(column string, transform func(any) any) {
+	columnTransforms.Store(column, transform)
+}
+
+// transformColumnValue applies transform registered for the column
+func transformColumnValue(column string, value any) any {
+	transform, exists := columnTransforms.Load(column)
+	if !exists {
+		return value
+	}
+	return transform.(func(any) any)(value)
+}
@@ -0,0 +1,229 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	reflectutils "github.com/raunlo/pgx-with-automapper/reflect_utils"
+)
+
+// EntityDescription describes how an entity is mapped from query results
+type EntityDescription struct {
+	Type          reflect.Type
+	PrimaryKey    string                  // Primary key column
+	Columns       []string                // Mapped columns in struct field order
+	Relationships map[string]reflect.Type // Maps struct field name -> relationship field type
+}
+
+// Warning reports a non-fatal mapping issue, e.g. a field which is silently skipped by the mapper
+type Warning struct {
+	Entity  reflect.Type
+	Field   string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s.%s: %s", w.Entity, w.Field, w.Message)
+}
+
+// AnalyzeWithReport analyzes the entity graph of v and describes how the entity is mapped. Unlike scanning, it also
+// reports issues which do not prevent mapping, but usually are mistakes: skipped fields, columns mapped by multiple
+// fields and fields of types the mapper cannot set. Warnings include all entities reachable through relationships.
+func AnalyzeWithReport(v interface{}) (EntityDescription, []Warning, error) {
+	entityType := reflect.TypeOf(v)
+	if entityType == nil {
+		return EntityDescription{}, nil, errors.New("entity cannot be nil")
+	}
+	entityType = reflectutils.DeReferencePointer(entityType)
+	if entityType.Kind() == reflect.Slice {
+		entityType = reflectutils.DeReferencePointer(entityType.Elem())
+	}
+	if entityType.Kind() != reflect.Struct {
+		return EntityDescription{}, nil, errors.New(fmt.Sprintf("entity(%s) must be a struct", entityType))
+	}
+
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		return EntityDescription{}, nil, err
+	}
+
+	description := EntityDescription{
+		Type:          entityType,
+		Columns:       orderedColumns(entityMappingInfo),
+		Relationships: make(map[string]reflect.Type, len(entityMappingInfo.Relationships)),
+	}
+	if entityMappingInfo.KeyField != nil {
+		description.PrimaryKey = strings.Join(entityMappingInfo.KeyField.columns(), ", ")
+	}
+	for fieldIndex, relationshipType := range entityMappingInfo.Relationships {
+		description.Relationships[entityType.Field(fieldIndex).Name] = relationshipType
+	}
+
+	return description, collectWarnings(entityType, make(map[reflect.Type]struct{})), nil
+}
+
+func collectWarnings(entityType reflect.Type, visited map[reflect.Type]struct{}) []Warning {
+	if _, seen := visited[entityType]; seen {
+		return nil
+	}
+	visited[entityType] = struct{}{}
+
+	return collectFieldWarnings(entityType, entityType, make(map[string]string), visited)
+}
+
+// collectFieldWarnings collects warnings for fields of the struct, which is either the entity or a struct embedded in it
+func collectFieldWarnings(entityType, structType reflect.Type, columnFields map[string]string, visited map[reflect.Type]struct{}) []Warning {
+	var warnings []Warning
+	addWarning := func(field reflect.StructField, message string) {
+		warnings = append(warnings, Warning{Entity: entityType, Field: field.Name, Message: message})
+	}
+
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		dbTag := field.Tag.Get("db")
+		primaryKeyTag := field.Tag.Get("primaryKey")
+		relationshipTag := field.Tag.Get("relationship")
+
+		var columnName string
+		switch {
+		case dbTag == "-" || primaryKeyTag == "-" || relationshipTag == "-":
+			continue
+		case relationshipTag != "":
+			if !field.IsExported() {
+				addWarning(field, "relationship on unexported field cannot be set")
+			}
+			elementType := reflectutils.DeReferencePointer(field.Type)
+			if elementType.Kind() == reflect.Slice {
+				elementType = reflectutils.DeReferencePointer(elementType.Elem())
+			}
+			if elementType.Kind() == reflect.Struct {
+				warnings = append(warnings, collectWarnings(elementType, visited)...)
+			}
+			continue
+		case primaryKeyTag != "" && dbTag != "":
+			columnName, _ = parseTag(dbTag)
+		case primaryKeyTag != "":
+			columnName, _ = parseTag(primaryKeyTag)
+		case dbTag != "":
+			columnName, _ = parseTag(dbTag)
+			if columnName == "" {
+				continue
+			}
+		case field.Anonymous && reflectutils.DeReferencePointer(field.Type).Kind() == reflect.Struct:
+			warnings = append(warnings, collectFieldWarnings(entityType, reflectutils.DeReferencePointer(field.Type), columnFields, visited)...)
+			continue
+		case !field.IsExported():
+			addWarning(field, "unexported field without tag is not mapped")
+			continue
+		case NamingStrategy == nil:
+			addWarning(field, "field without tag is not mapped")
+			continue
+		default:
+			columnName = NamingStrategy(field.Name)
+		}
+
+		if !field.IsExported() {
+			addWarning(field, fmt.Sprintf("column %s is mapped to unexported field, which cannot be set", columnName))
+		}
+		if otherField, exists := columnFields[columnName]; exists {
+			addWarning(field, fmt.Sprintf("column %s is also mapped by field %s", columnName, otherField))
+		} else {
+			columnFields[columnName] = field.Name
+		}
+		if !isSupportedFieldType(field.Type) {
+			addWarning(field, fmt.Sprintf("field type %s is not supported by the mapper", field.Type))
+		}
+	}
+	return warnings
+}
+
+// isSupportedFieldType reports whether setFieldValue can set values into the fields of given type
+func isSupportedFieldType(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8,
+		reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8,
+		reflect.String, reflect.Bool, reflect.Float64, reflect.Struct, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	case reflect.Ptr:
+		return isSupportedFieldType(fieldType.Elem())
+	default:
+		return false
+	}
+}
+
+// PrintEntityGraph returns human-readable tree of columns and relationships the entity of v is mapped with, e.g.
+//
+//	mapper.order (primary key: order_id)
+//	  columns: order_id, total
+//	  Items: oneToMany []mapper.item (primary key: item_id)
+//	    columns: item_id, name
+//
+// Relationships back to an entity already printed on the path are marked as recursive instead of printed again.
+func PrintEntityGraph(v interface{}) string {
+	entityType := reflect.TypeOf(v)
+	if entityType == nil {
+		return "entity cannot be nil"
+	}
+	entityType = reflectutils.DeReferencePointer(entityType)
+	if entityType.Kind() == reflect.Slice {
+		entityType = reflectutils.DeReferencePointer(entityType.Elem())
+	}
+
+	var builder strings.Builder
+	builder.WriteString(entityType.String())
+	printEntityGraph(&builder, entityType, 1, make(map[reflect.Type]struct{}))
+	return builder.String()
+}
+
+// printEntityGraph prints the rest of entity's header line and its columns and relationships at the given depth
+func printEntityGraph(builder *strings.Builder, entityType reflect.Type, depth int, path map[reflect.Type]struct{}) {
+	if _, recursive := path[entityType]; recursive {
+		builder.WriteString(" (recursive)\n")
+		return
+	}
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		builder.WriteString(fmt.Sprintf(" (invalid: %v)\n", err))
+		return
+	}
+	path[entityType] = struct{}{}
+	defer delete(path, entityType)
+
+	if entityMappingInfo.KeyField != nil {
+		builder.WriteString(fmt.Sprintf(" (primary key: %s)", strings.Join(entityMappingInfo.KeyField.columns(), ", ")))
+	}
+	builder.WriteString("\n")
+
+	indent := strings.Repeat("  ", depth)
+	builder.WriteString(fmt.Sprintf("%scolumns: %s\n", indent, strings.Join(orderedColumns(entityMappingInfo), ", ")))
+
+	fieldIndexes := make([]int, 0, len(entityMappingInfo.Relationships))
+	for fieldIndex := range entityMappingInfo.Relationships {
+		fieldIndexes = append(fieldIndexes, fieldIndex)
+	}
+	sort.Ints(fieldIndexes)
+	for _, fieldIndex := range fieldIndexes {
+		field := entityType.Field(fieldIndex)
+		relationshipType := reflectutils.DeReferencePointer(field.Type)
+		isSlice := relationshipType.Kind() == reflect.Slice
+		if isSlice {
+			relationshipType = reflectutils.DeReferencePointer(relationshipType.Elem())
+		}
+
+		builder.WriteString(fmt.Sprintf("%s%s: %s ", indent, field.Name, field.Tag.Get("relationship")))
+		if isSlice {
+			builder.WriteString("[]")
+		}
+		builder.WriteString(relationshipType.String())
+		if prefix, hasPrefix := entityMappingInfo.RelationshipPrefixes[fieldIndex]; hasPrefix {
+			builder.WriteString(fmt.Sprintf(" prefix %s", prefix))
+		}
+		if keyColumn, sharesKey := entityMappingInfo.RelationshipKeys[fieldIndex]; sharesKey {
+			builder.WriteString(fmt.Sprintf(" key %s", keyColumn))
+		}
+		printEntityGraph(builder, relationshipType, depth+1, path)
+	}
+}
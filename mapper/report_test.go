@@ -0,0 +1,94 @@
+package mapper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeWithReport(t *testing.T) {
+	type comment struct {
+		CommentId uint `primaryKey:"comment_id"`
+		Body      string
+		author    string //nolint:unused // Suppress U1000 from staticcheck
+	}
+	type post struct {
+		PostId    uint      `primaryKey:"post_id"`
+		Title     string    `db:"title"`
+		Headline  string    `db:"title"`
+		Score     float32   `db:"score"`
+		Updates   chan int  `db:"-"`
+		Comments  []comment `relationship:"oneToMany"`
+		Published bool
+	}
+	defaultStrategy := NamingStrategy
+	NamingStrategy = nil
+	defer func() { NamingStrategy = defaultStrategy }()
+
+	description, warnings, err := AnalyzeWithReport(&post{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, EntityDescription{
+		Type:          reflect.TypeOf(post{}),
+		PrimaryKey:    "post_id",
+		Columns:       []string{"post_id", "title", "score"},
+		Relationships: map[string]reflect.Type{"Comments": reflect.TypeOf([]comment{})},
+	}, description)
+	assert.Equal(t, []string{
+		"mapper.post.Headline: column title is also mapped by field Title",
+		"mapper.post.Score: field type float32 is not supported by the mapper",
+		"mapper.comment.Body: field without tag is not mapped",
+		"mapper.comment.author: unexported field without tag is not mapped",
+		"mapper.post.Published: field without tag is not mapped",
+	}, warningMessages(warnings))
+}
+
+func TestAnalyzeWithReportReturnsErrorForMalformedEntity(t *testing.T) {
+	type malformed struct {
+		FirstId  uint `primaryKey:"first_id"`
+		SecondId uint `primaryKey:"second_id"`
+	}
+
+	_, _, err := AnalyzeWithReport(malformed{})
+
+	assert.EqualError(t, err, "multiple primary key fields found")
+}
+
+func warningMessages(warnings []Warning) []string {
+	messages := make([]string, len(warnings))
+	for i, warning := range warnings {
+		messages[i] = warning.String()
+	}
+	return messages
+}
+
+func TestPrintEntityGraph(t *testing.T) {
+	type author struct {
+		AuthorId uint   `primaryKey:"author_id"`
+		Name     string `db:"author_name"`
+	}
+	type reply struct {
+		ReplyId uint    `primaryKey:"reply_id"`
+		Body    string  `db:"reply_body"`
+		Author  *author `relationship:"oneToOne"`
+	}
+	type thread struct {
+		ThreadId uint    `primaryKey:"thread_id"`
+		Title    string  `db:"title"`
+		Replies  []reply `relationship:"oneToMany"`
+		Author   author  `relationship:"oneToOne" prefix:"thread_"`
+	}
+
+	printed := PrintEntityGraph([]thread{})
+
+	assert.Equal(t, `mapper.thread (primary key: thread_id)
+  columns: thread_id, title
+  Replies: oneToMany []mapper.reply (primary key: reply_id)
+    columns: reply_id, reply_body
+    Author: oneToOne mapper.author (primary key: author_id)
+      columns: author_id, author_name
+  Author: oneToOne mapper.author prefix thread_ (primary key: author_id)
+    columns: author_id, author_name
+`, printed)
+}
@@ -0,0 +1,82 @@
+package mapper
+
+import (
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// SliceScanner maps rows into entities which are reused between scans. It avoids allocating new entities for every
+// scan in services repeatedly processing result sets of similar size. SliceScanner is not safe for concurrent use.
+type SliceScanner[T any] struct {
+	entities []*T // allocated entities, the first used ones are returned from Scan
+	result   []*T // reused result slice
+}
+
+// NewSliceScanner creates a SliceScanner with capacity entities allocated upfront
+func NewSliceScanner[T any](capacity int) *SliceScanner[T] {
+	scanner := &SliceScanner[T]{
+		entities: make([]*T, capacity),
+		result:   make([]*T, 0, capacity),
+	}
+	for i := range scanner.entities {
+		scanner.entities[i] = new(T)
+	}
+	return scanner
+}
+
+// Scan maps rows like ScanMany does, but into reused entities, which are reset to zero values before mapping. The
+// returned slice and entities are valid only until the next call of Scan.
+func (s *SliceScanner[T]) Scan(rows pgx.Rows) ([]*T, error) {
+	defer rows.Close()
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+	if entityType.Kind() != reflect.Struct {
+		return nil, errors.New("entity type must be a struct")
+	}
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPrimaryKey(entityType, entityMappingInfo); err != nil {
+		return nil, err
+	}
+
+	state := newScanState()
+	s.result = s.result[:0]
+	for rows.Next() {
+		rowInMap, err := rowToMap(rows)
+		if err != nil {
+			return nil, err
+		}
+		if skipsRow(entityMappingInfo, rowInMap) {
+			continue
+		}
+
+		keyValue, _ := entityMappingInfo.KeyField.keyValue(rowInMap)
+		_, entityExists := state.lookup[entityType][keyValue]
+		var dest *T
+		if !entityExists {
+			dest = s.nextEntity()
+			s.result = append(s.result, dest)
+		}
+
+		if _, err = mapToStruct(entityType, rowInMap, state, dest); err != nil {
+			return nil, err
+		}
+	}
+	return s.result, rows.Err()
+}
+
+// nextEntity returns the next unused entity reset to zero value, allocating it when all entities are in use
+func (s *SliceScanner[T]) nextEntity() *T {
+	if len(s.result) < len(s.entities) {
+		entity := s.entities[len(s.result)]
+		var zero T
+		*entity = zero
+		return entity
+	}
+	entity := new(T)
+	s.entities = append(s.entities, entity)
+	return entity
+}
@@ -0,0 +1,87 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type team struct {
+	TeamId  uint   `primaryKey:"team_id"`
+	Name    string `db:"team_name"`
+	Members []user `relationship:"oneToMany"`
+}
+
+func TestSliceScanner(t *testing.T) {
+	scanner := NewSliceScanner[team](1)
+	scan := func(rows [][]interface{}) []*team {
+		mock := setupPostgresMock(t, "^SELECT (.+) FROM teams$", rows,
+			[]string{"team_id", "team_name", "user_id", "user_name"})
+		pgxRows, err := mock.Query(context.Background(), "SELECT * FROM teams")
+		assert.NoError(t, err)
+
+		result, err := scanner.Scan(pgxRows)
+		assert.NoError(t, err)
+		return result
+	}
+
+	firstResult := scan([][]interface{}{{1, "core", 1, "John"}, {1, "core", 2, "Jane"}, {2, "web", 3, "Jack"}})
+	assert.Equal(t, []*team{
+		{TeamId: 1, Name: "core", Members: []user{{UserId: 1, Name: "John"}, {UserId: 2, Name: "Jane"}}},
+		{TeamId: 2, Name: "web", Members: []user{{UserId: 3, Name: "Jack"}}},
+	}, firstResult)
+	firstEntity := firstResult[0]
+
+	secondResult := scan([][]interface{}{{3, "data", 4, "Jill"}})
+	assert.Equal(t, []*team{{TeamId: 3, Name: "data", Members: []user{{UserId: 4, Name: "Jill"}}}}, secondResult)
+	assert.Same(t, firstEntity, secondResult[0])
+}
+
+func newBenchmarkRows(b *testing.B, rowCount int) pgx.Rows {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		b.Fatalf("unexpected error opening mock DB: %s", err)
+	}
+	mockRows := mock.NewRows([]string{"team_id", "team_name", "user_id", "user_name"})
+	for i := 0; i < rowCount; i++ {
+		mockRows.AddRow(i, fmt.Sprintf("team-%d", i), i, fmt.Sprintf("user-%d", i))
+	}
+	mock.ExpectQuery("^SELECT (.+) FROM teams$").WillReturnRows(mockRows)
+	rows, err := mock.Query(context.Background(), "SELECT * FROM teams")
+	if err != nil {
+		b.Fatalf("unexpected error querying mock DB: %s", err)
+	}
+	return rows
+}
+
+func BenchmarkScanManyRepeatedScans(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		rows := newBenchmarkRows(b, 100)
+		b.StartTimer()
+
+		var result []team
+		if err := ScanMany(rows, &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSliceScannerRepeatedScans(b *testing.B) {
+	scanner := NewSliceScanner[team](100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		rows := newBenchmarkRows(b, 100)
+		b.StartTimer()
+
+		if _, err := scanner.Scan(rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
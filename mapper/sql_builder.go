@@ -0,0 +1,214 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	reflectutils "github.com/raunlo/pgx-with-automapper/reflect_utils"
+)
+
+// Columns returns db column names of the entity in struct field order. Relationship fields are not included.
+func Columns(entityType reflect.Type) ([]string, error) {
+	if entityType == nil {
+		return nil, errors.New("entity type cannot be nil")
+	}
+	entityType = reflectutils.DeReferencePointer(entityType)
+	if entityType.Kind() != reflect.Struct {
+		return nil, errors.New(fmt.Sprintf("entity(%s) must be a struct", entityType))
+	}
+
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		return nil, err
+	}
+	return orderedColumns(entityMappingInfo), nil
+}
+
+// InsertColumns returns columns of the entity like Columns, but without columns generated by the database, tagged
+// with `primaryKey:"id,generated"` or `db:"column,auto"`
+func InsertColumns(entityType reflect.Type) ([]string, error) {
+	columns, err := Columns(entityType)
+	if err != nil {
+		return nil, err
+	}
+	entityMappingInfo, err := getMappingInfo(reflectutils.DeReferencePointer(entityType))
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(columns, func(column string) bool {
+		return entityMappingInfo.ColumnOptions[column].generated
+	}), nil
+}
+
+// BindStruct returns field values of the entity keyed by db column name
+func BindStruct(entity interface{}) (pgx.NamedArgs, error) {
+	entityValue := reflect.Indirect(reflect.ValueOf(entity))
+	if !entityValue.IsValid() || entityValue.Kind() != reflect.Struct {
+		return nil, errors.New("entity must be a struct or a pointer to a struct")
+	}
+
+	entityMappingInfo, err := getMappingInfo(entityValue.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	args := make(pgx.NamedArgs, len(entityMappingInfo.FieldMapping))
+	for columnName, structIndex := range entityMappingInfo.FieldMapping {
+		if field := fieldByIndex(entityValue, structIndex, false); field.IsValid() {
+			args[columnName] = field.Interface()
+		} else {
+			// column of nil embedded struct pointer
+			args[columnName] = nil
+		}
+	}
+	return args, nil
+}
+
+// BuildInsert builds INSERT statement for the entity with named arguments holding its field values. Columns generated
+// by the database, tagged with `primaryKey:"id,generated"` or `db:"column,auto"`, are omitted.
+func BuildInsert(table string, entity interface{}) (string, pgx.NamedArgs, error) {
+	return NewInsertBuilder(table).Build(entity)
+}
+
+// BuildUpsert builds INSERT statement for the entity like BuildInsert, updating all other inserted columns when a row
+// conflicting on conflictColumns exists. Primary key columns of the entity are the conflict target when no
+// conflictColumns are given.
+func BuildUpsert(table string, entity interface{}, conflictColumns []string) (string, pgx.NamedArgs, error) {
+	sql, args, err := BuildInsert(table, entity)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entityMappingInfo, err := getMappingInfo(reflect.Indirect(reflect.ValueOf(entity)).Type())
+	if err != nil {
+		return "", nil, err
+	}
+	if len(conflictColumns) == 0 {
+		if entityMappingInfo.KeyField == nil {
+			return "", nil, errors.New(fmt.Sprintf("entity(%T) has no primary key, conflict columns must be given", entity))
+		}
+		conflictColumns = entityMappingInfo.KeyField.columns()
+	}
+	for _, column := range conflictColumns {
+		if _, ok := entityMappingInfo.FieldMapping[column]; !ok {
+			return "", nil, errors.New(fmt.Sprintf("conflict column %s is not a mapped column", column))
+		}
+	}
+
+	columns, err := InsertColumns(reflect.TypeOf(entity))
+	if err != nil {
+		return "", nil, err
+	}
+	onConflict, err := BuildOnConflict(reflect.TypeOf(entity), columns, conflictColumns)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql + " " + onConflict, args, nil
+}
+
+// BuildOnConflict builds ON CONFLICT clause updating the inserted columns of the entity with values proposed for
+// insertion when a row conflicting on conflictColumns exists. Conflict columns and columns generated by the database
+// are not updated. Column names are quoted.
+func BuildOnConflict(entityType reflect.Type, columns []string, conflictColumns []string) (string, error) {
+	entityMappingInfo, err := getMappingInfo(reflectutils.DeReferencePointer(entityType))
+	if err != nil {
+		return "", err
+	}
+
+	assignments := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if slices.Contains(conflictColumns, column) || entityMappingInfo.ColumnOptions[column].generated {
+			continue
+		}
+		quotedColumn := pgx.Identifier{column}.Sanitize()
+		assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", quotedColumn, quotedColumn))
+	}
+	conflictTarget := strings.Join(quoteColumns(conflictColumns), ", ")
+	if len(assignments) == 0 {
+		return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", conflictTarget), nil
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", conflictTarget, strings.Join(assignments, ", ")), nil
+}
+
+// SanitizeTable quotes the table name as SQL identifier. Table may be schema qualified, e.g. public.products, in which
+// case both parts are quoted.
+func SanitizeTable(table string) string {
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize()
+}
+
+// quoteColumns returns the column names quoted as SQL identifiers
+func quoteColumns(columns []string) []string {
+	quotedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = pgx.Identifier{column}.Sanitize()
+	}
+	return quotedColumns
+}
+
+// InsertBuilder builds INSERT statements of tagged entities into a table
+type InsertBuilder struct {
+	table               string
+	returningPrimaryKey bool
+}
+
+// NewInsertBuilder returns InsertBuilder for the table
+func NewInsertBuilder(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// ReturningPrimaryKey adds RETURNING clause with primary key columns of the entity to built statements
+func (b *InsertBuilder) ReturningPrimaryKey() *InsertBuilder {
+	b.returningPrimaryKey = true
+	return b
+}
+
+// Build builds INSERT statement for the entity with named arguments holding its field values, omitting columns
+// generated by the database
+func (b *InsertBuilder) Build(entity interface{}) (string, pgx.NamedArgs, error) {
+	values, err := BindStruct(entity)
+	if err != nil {
+		return "", nil, err
+	}
+	columns, err := InsertColumns(reflect.TypeOf(entity))
+	if err != nil {
+		return "", nil, err
+	}
+
+	placeholders := make([]string, 0, len(columns))
+	args := make(pgx.NamedArgs, len(columns))
+	for _, column := range columns {
+		placeholders = append(placeholders, "@"+column)
+		args[column] = values[column]
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		SanitizeTable(b.table), strings.Join(quoteColumns(columns), ", "), strings.Join(placeholders, ", "))
+	if b.returningPrimaryKey {
+		entityMappingInfo, err := getMappingInfo(reflect.Indirect(reflect.ValueOf(entity)).Type())
+		if err != nil {
+			return "", nil, err
+		}
+		if entityMappingInfo.KeyField == nil {
+			return "", nil, errors.New(fmt.Sprintf("entity(%T) has no primary key to return", entity))
+		}
+		sql += " RETURNING " + strings.Join(quoteColumns(entityMappingInfo.KeyField.columns()), ", ")
+	}
+	return sql, args, nil
+}
+
+// orderedColumns returns mapped columns sorted by struct field index, so that generated statements are stable
+func orderedColumns(entityMappingInfo *MappingInfo) []string {
+	columns := make([]string, 0, len(entityMappingInfo.FieldMapping))
+	for columnName := range entityMappingInfo.FieldMapping {
+		columns = append(columns, columnName)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return slices.Compare(entityMappingInfo.FieldMapping[columns[i]], entityMappingInfo.FieldMapping[columns[j]]) < 0
+	})
+	return columns
+}
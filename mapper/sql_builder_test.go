@@ -0,0 +1,122 @@
+package mapper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type product struct {
+	ProductId uint    `primaryKey:"product_id"`
+	Name      string  `db:"name"`
+	Price     float64 `db:"price"`
+	Owner     *user   `relationship:"oneToOne"`
+}
+
+func TestColumns(t *testing.T) {
+	columns, err := Columns(reflect.TypeOf(&product{}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"product_id", "name", "price"}, columns)
+
+	_, err = Columns(reflect.TypeOf(1))
+	assert.ErrorContains(t, err, "entity(int) must be a struct")
+}
+
+func TestBindStruct(t *testing.T) {
+	args, err := BindStruct(product{ProductId: 1, Name: "Chair", Price: 9.5, Owner: &user{UserId: 1}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, pgx.NamedArgs{"product_id": uint(1), "name": "Chair", "price": 9.5}, args)
+
+	_, err = BindStruct([]product{})
+	assert.ErrorContains(t, err, "entity must be a struct or a pointer to a struct")
+}
+
+func TestBuildInsert(t *testing.T) {
+	type customer struct {
+		CustomerId int    `primaryKey:"customer_id,generated"`
+		Name       string `db:"name"`
+		Revision   int    `db:"revision,auto"`
+		Email      string `db:"email"`
+	}
+
+	sql, args, err := BuildInsert("customers", &customer{CustomerId: 7, Name: "John", Revision: 3, Email: "john@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "customers" ("name", "email") VALUES (@name, @email)`, sql)
+	assert.Equal(t, pgx.NamedArgs{"name": "John", "email": "john@example.com"}, args)
+
+	mappingInfo, _ := GetEntityGraphMappingInfo(reflect.TypeOf(customer{}))
+	assert.Equal(t, "customer_id", mappingInfo.KeyField.dbPrimaryKeyName)
+}
+
+func TestBuildInsertQuotesIdentifiers(t *testing.T) {
+	type grant struct {
+		GrantId int    `primaryKey:"grant_id"`
+		Group   string `db:"group"`
+		User    string `db:"user"`
+	}
+
+	sql, args, err := BuildInsert("acl.grants", grant{GrantId: 1, Group: "admins", User: "john"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "acl"."grants" ("grant_id", "group", "user") VALUES (@grant_id, @group, @user)`, sql)
+	assert.Equal(t, pgx.NamedArgs{"grant_id": 1, "group": "admins", "user": "john"}, args)
+}
+
+func TestInsertBuilderReturningPrimaryKey(t *testing.T) {
+	type order struct {
+		OrderId int    `primaryKey:"order_id,generated"`
+		Status  string `db:"status"`
+	}
+
+	sql, args, err := NewInsertBuilder("orders").ReturningPrimaryKey().Build(order{Status: "new"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "orders" ("status") VALUES (@status) RETURNING "order_id"`, sql)
+	assert.Equal(t, pgx.NamedArgs{"status": "new"}, args)
+
+	type note struct {
+		Text string `db:"text"`
+	}
+	_, _, err = NewInsertBuilder("notes").ReturningPrimaryKey().Build(note{Text: "hello"})
+	assert.ErrorContains(t, err, "has no primary key to return")
+}
+
+func TestBuildUpsert(t *testing.T) {
+	sql, args, err := BuildUpsert("products", product{ProductId: 1, Name: "Chair", Price: 9.5}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "products" ("product_id", "name", "price") VALUES (@product_id, @name, @price) `+
+		`ON CONFLICT ("product_id") DO UPDATE SET "name" = EXCLUDED."name", "price" = EXCLUDED."price"`, sql)
+	assert.Equal(t, pgx.NamedArgs{"product_id": uint(1), "name": "Chair", "price": 9.5}, args)
+
+	sql, _, err = BuildUpsert("products", product{ProductId: 1, Name: "Chair"}, []string{"name"})
+	assert.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "products" ("product_id", "name", "price") VALUES (@product_id, @name, @price) `+
+		`ON CONFLICT ("name") DO UPDATE SET "product_id" = EXCLUDED."product_id", "price" = EXCLUDED."price"`, sql)
+
+	_, _, err = BuildUpsert("products", product{}, []string{"sku"})
+	assert.ErrorContains(t, err, "conflict column sku is not a mapped column")
+}
+
+func TestBuildOnConflict(t *testing.T) {
+	type membership struct {
+		MemberId  int    `primaryKey:"member_id,generated"`
+		Email     string `db:"email"`
+		Group     string `db:"group"`
+		UpdatedAt string `db:"updated_at,auto"`
+	}
+	entityType := reflect.TypeOf(membership{})
+
+	clause, err := BuildOnConflict(entityType, []string{"email", "group", "updated_at"}, []string{"email"})
+	assert.NoError(t, err)
+	assert.Equal(t, `ON CONFLICT ("email") DO UPDATE SET "group" = EXCLUDED."group"`, clause)
+
+	clause, err = BuildOnConflict(entityType, []string{"member_id", "email"}, []string{"email"})
+	assert.NoError(t, err)
+	assert.Equal(t, `ON CONFLICT ("email") DO NOTHING`, clause)
+}
@@ -0,0 +1,132 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	reflectutils "github.com/raunlo/pgx-with-automapper/reflect_utils"
+)
+
+// ScanTree assembles adjacency rows, e.g. result of a recursive CTE, into trees of self-referential entities like
+// category with field Children []Category tagged `relationship:"oneToMany"`. parentKeyColumn holds the primary key of the row's
+// parent. Rows without parent, or whose parent is not in the result, are roots, which are scanned into dest slice.
+// Children keep the order of their rows.
+func ScanTree(rows pgx.Rows, dest interface{}, parentKeyColumn string) error {
+	defer rows.Close()
+	destinationPtrValue := reflect.ValueOf(dest)
+	if dest == nil || destinationPtrValue.Kind() != reflect.Ptr || destinationPtrValue.Elem().Kind() != reflect.Slice {
+		return errors.New("dest must be a pointer to a slice")
+	}
+	destinationValue := destinationPtrValue.Elem()
+	entityType := reflectutils.DeReferencePointer(destinationValue.Type().Elem())
+	if entityType.Kind() != reflect.Struct {
+		return errors.New("dest must be a slice of structs")
+	}
+	entityMappingInfo, err := getMappingInfo(entityType)
+	if err != nil {
+		return err
+	}
+	if err := checkPrimaryKey(entityType, entityMappingInfo); err != nil {
+		return err
+	}
+	childrenFieldIndex, err := treeChildrenField(entityType, entityMappingInfo)
+	if err != nil {
+		return err
+	}
+
+	state := newScanState()
+	var keys []interface{}
+	parentKeys := make(map[interface{}]interface{})
+	for rows.Next() {
+		rowInMap, err := rowToMap(rows)
+		if err != nil {
+			return err
+		}
+		if skipsRow(entityMappingInfo, rowInMap) {
+			continue
+		}
+		parentKey, exists := rowInMap[parentKeyColumn]
+		if !exists {
+			return errors.New(fmt.Sprintf("parent key column %s not found in result", parentKeyColumn))
+		}
+
+		keyValue, _ := entityMappingInfo.KeyField.keyValue(rowInMap)
+		if _, seen := state.lookup[entityType][keyValue]; !seen {
+			keys = append(keys, keyValue)
+			parentKeys[keyValue] = parentKey
+		}
+		if _, err = mapToStruct(entityType, rowInMap, state, reflect.New(entityType).Interface()); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	nodes := state.lookup[entityType]
+	children := make(map[interface{}][]interface{})
+	var roots []interface{}
+	for _, key := range keys {
+		parentKey := parentKeys[key]
+		if _, parentExists := nodes[parentKey]; parentKey == nil || !parentExists || parentKey == key {
+			roots = append(roots, key)
+		} else {
+			children[parentKey] = append(children[parentKey], key)
+		}
+	}
+
+	// children are assembled before they are added to their parent, so slices of values hold complete copies
+	assembled := 0
+	var assemble func(key interface{})
+	assemble = func(key interface{}) {
+		assembled++
+		node := nodes[key]
+		for _, childKey := range children[key] {
+			assemble(childKey)
+			appendTreeChild(node.Elem().Field(childrenFieldIndex), nodes[childKey])
+		}
+	}
+
+	result := reflect.MakeSlice(destinationValue.Type(), 0, len(roots))
+	for _, key := range roots {
+		assemble(key)
+		if destinationValue.Type().Elem().Kind() == reflect.Ptr {
+			result = reflect.Append(result, nodes[key])
+		} else {
+			result = reflect.Append(result, nodes[key].Elem())
+		}
+	}
+	if assembled != len(keys) {
+		return errors.New(fmt.Sprintf("rows of %s form a cycle, which is not reachable from any root", entityType))
+	}
+
+	destinationValue.Set(result)
+	return nil
+}
+
+// treeChildrenField returns index of the slice relationship holding children of the same entity type
+func treeChildrenField(entityType reflect.Type, entityMappingInfo *MappingInfo) (int, error) {
+	for fieldIndex, relationshipType := range entityMappingInfo.Relationships {
+		relationshipType = reflectutils.DeReferencePointer(relationshipType)
+		if relationshipType.Kind() == reflect.Slice && reflectutils.DeReferencePointer(relationshipType.Elem()) == entityType {
+			return fieldIndex, nil
+		}
+	}
+	return 0, errors.New(fmt.Sprintf("entity %s has no self-referential slice relationship", entityType))
+}
+
+// appendTreeChild appends pointer to the child into children slice of pointers or values
+func appendTreeChild(field reflect.Value, child reflect.Value) {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	if field.Type().Elem().Kind() != reflect.Ptr {
+		child = child.Elem()
+	}
+	field.Set(reflect.Append(field, child))
+}
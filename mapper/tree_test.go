@@ -0,0 +1,83 @@
+package mapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type category struct {
+	CategoryId uint       `primaryKey:"category_id"`
+	Name       string     `db:"name"`
+	Children   []category `relationship:"oneToMany"`
+}
+
+func TestScanTree(t *testing.T) {
+	mock := setupPostgresMock(t, "^WITH RECURSIVE (.+)$",
+		[][]interface{}{
+			{1, nil, "electronics"},
+			{2, 1, "computers"},
+			{3, 2, "laptops"},
+			{4, 1, "phones"},
+			{5, nil, "books"},
+			{6, 2, "desktops"},
+		},
+		[]string{"category_id", "parent_id", "name"})
+	rows, err := mock.Query(context.Background(), "WITH RECURSIVE tree AS (...) SELECT * FROM tree")
+	assert.NoError(t, err)
+
+	var result []category
+	err = ScanTree(rows, &result, "parent_id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []category{
+		{CategoryId: 1, Name: "electronics", Children: []category{
+			{CategoryId: 2, Name: "computers", Children: []category{
+				{CategoryId: 3, Name: "laptops"},
+				{CategoryId: 6, Name: "desktops"},
+			}},
+			{CategoryId: 4, Name: "phones"},
+		}},
+		{CategoryId: 5, Name: "books"},
+	}, result)
+}
+
+func TestScanTreeIntoPointers(t *testing.T) {
+	type node struct {
+		NodeId   uint    `primaryKey:"node_id"`
+		Children []*node `relationship:"oneToMany"`
+	}
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM nodes$",
+		[][]interface{}{{2, 1}, {1, nil}, {3, 2}},
+		[]string{"node_id", "parent_id"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM nodes")
+	assert.NoError(t, err)
+
+	var result []*node
+	err = ScanTree(rows, &result, "parent_id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*node{{NodeId: 1, Children: []*node{{NodeId: 2, Children: []*node{{NodeId: 3}}}}}}, result)
+}
+
+func TestScanTreeErrors(t *testing.T) {
+	type flat struct {
+		FlatId uint `primaryKey:"flat_id"`
+	}
+	cycleMock := setupPostgresMock(t, "^SELECT (.+) FROM categories$",
+		[][]interface{}{{1, 2, "a"}, {2, 1, "b"}},
+		[]string{"category_id", "parent_id", "name"})
+	rows, err := cycleMock.Query(context.Background(), "SELECT * FROM categories")
+	assert.NoError(t, err)
+	var categories []category
+	err = ScanTree(rows, &categories, "parent_id")
+	assert.EqualError(t, err, "rows of mapper.category form a cycle, which is not reachable from any root")
+
+	flatMock := setupPostgresMock(t, "^SELECT (.+) FROM flats$", [][]interface{}{{1, nil}}, []string{"flat_id", "parent_id"})
+	rows, err = flatMock.Query(context.Background(), "SELECT * FROM flats")
+	assert.NoError(t, err)
+	var flats []flat
+	err = ScanTree(rows, &flats, "parent_id")
+	assert.EqualError(t, err, "entity mapper.flat has no self-referential slice relationship")
+}
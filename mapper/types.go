@@ -0,0 +1,146 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Range is a Postgres range, e.g. int8range or tstzrange, with bounds of type T. Unbounded side of the range has
+// pgtype.Unbounded bound type and zero value bound.
+type Range[T any] struct {
+	Lower     T
+	Upper     T
+	LowerType pgtype.BoundType
+	UpperType pgtype.BoundType
+	Valid     bool
+}
+
+// isNullableType reports whether fields of the type can tell NULL apart from values: pointers, interfaces, maps,
+// slices, registered generic wrappers and structs with Valid field like sql.NullString and pgtype types
+func isNullableType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
+	case reflect.Struct:
+		if _, isWrapper := getGenericWrapper(t); isWrapper {
+			return true
+		}
+		validField, hasValid := t.FieldByName("Valid")
+		return hasValid && validField.Type.Kind() == reflect.Bool
+	default:
+		return false
+	}
+}
+
+// isRangeType reports whether the type has the shape of Range, which pgtype.Range also has
+func isRangeType(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	boundType := reflect.TypeOf(pgtype.BoundType(0))
+	for _, name := range []string{"LowerType", "UpperType"} {
+		if field, exists := t.FieldByName(name); !exists || field.Type != boundType {
+			return false
+		}
+	}
+	for _, name := range []string{"Lower", "Upper", "Valid"} {
+		if _, exists := t.FieldByName(name); !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// TimeOfDay is a time without date, scanned from time and timetz columns. UTC offset of timetz is not kept.
+type TimeOfDay struct {
+	Hour        int
+	Minute      int
+	Second      int
+	Microsecond int
+}
+
+// SinceMidnight returns the duration from midnight to the time of day
+func (t TimeOfDay) SinceMidnight() time.Duration {
+	return time.Duration(t.Hour)*time.Hour + time.Duration(t.Minute)*time.Minute +
+		time.Duration(t.Second)*time.Second + time.Duration(t.Microsecond)*time.Microsecond
+}
+
+func (t TimeOfDay) String() string {
+	if t.Microsecond != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d.%06d", t.Hour, t.Minute, t.Second, t.Microsecond)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+}
+
+func timeOfDayFromMicroseconds(microseconds int64) TimeOfDay {
+	return TimeOfDay{
+		Hour:        int(microseconds / int64(time.Hour/time.Microsecond)),
+		Minute:      int(microseconds / int64(time.Minute/time.Microsecond) % 60),
+		Second:      int(microseconds / int64(time.Second/time.Microsecond) % 60),
+		Microsecond: int(microseconds % int64(time.Second/time.Microsecond)),
+	}
+}
+
+// intervalDuration converts interval into duration. Days are 24 hours and months are 30 days, like EXTRACT(EPOCH FROM
+// interval) counts them.
+func intervalDuration(interval pgtype.Interval) time.Duration {
+	days := int64(interval.Days) + int64(interval.Months)*30
+	return time.Duration(interval.Microseconds)*time.Microsecond + time.Duration(days)*24*time.Hour
+}
+
+// timeOfDayLayouts are text formats of time and timetz values. pgx returns timetz values as text.
+var timeOfDayLayouts = []string{"15:04:05.999999", "15:04:05.999999Z07", "15:04:05.999999Z07:00"}
+
+func parseTimeOfDay(text string) (TimeOfDay, error) {
+	for _, layout := range timeOfDayLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return TimeOfDay{
+				Hour:        parsed.Hour(),
+				Minute:      parsed.Minute(),
+				Second:      parsed.Second(),
+				Microsecond: parsed.Nanosecond() / int(time.Microsecond),
+			}, nil
+		}
+	}
+	return TimeOfDay{}, fmt.Errorf("invalid time of day: %s", text)
+}
+
+// unwrapPgtype returns the Go value held by common pgtype wrappers, which pgx returns depending on its configuration
+// instead of plain values, e.g. pgtype.Timestamptz instead of time.Time. Returns nil value for invalid wrappers holding
+// NULL. pgtype.Numeric is converted by the numeric setters.
+func unwrapPgtype(value any) (any, bool) {
+	switch wrapper := value.(type) {
+	case pgtype.Timestamptz:
+		return validOrNil(wrapper.Time, wrapper.Valid), true
+	case pgtype.Timestamp:
+		return validOrNil(wrapper.Time, wrapper.Valid), true
+	case pgtype.Date:
+		return validOrNil(wrapper.Time, wrapper.Valid), true
+	case pgtype.Int2:
+		return validOrNil(wrapper.Int16, wrapper.Valid), true
+	case pgtype.Int4:
+		return validOrNil(wrapper.Int32, wrapper.Valid), true
+	case pgtype.Int8:
+		return validOrNil(wrapper.Int64, wrapper.Valid), true
+	case pgtype.Float4:
+		return validOrNil(float64(wrapper.Float32), wrapper.Valid), true
+	case pgtype.Float8:
+		return validOrNil(wrapper.Float64, wrapper.Valid), true
+	case pgtype.Text:
+		return validOrNil(wrapper.String, wrapper.Valid), true
+	case pgtype.Bool:
+		return validOrNil(wrapper.Bool, wrapper.Valid), true
+	default:
+		return nil, false
+	}
+}
+
+func validOrNil(value any, valid bool) any {
+	if !valid {
+		return nil
+	}
+	return value
+}
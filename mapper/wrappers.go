@@ -0,0 +1,73 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// genericWrapper describes fields of a registered generic wrapper type, e.g. Optional[T]
+type genericWrapper struct {
+	valueField    string // field receiving the column value
+	presenceField string // bool field set to true, when the column is not NULL
+}
+
+// genericWrapperKey identifies generic type regardless of its type arguments
+type genericWrapperKey struct {
+	pkgPath string
+	name    string
+}
+
+var (
+	genericWrappers = sync.Map{}
+)
+
+// RegisterGenericWrapper teaches the mapper to populate all instantiations of the generic wrapper type of example,
+// e.g. for `type Optional[T any] struct{ Value T; Set bool }`:
+//
+//	err := mapper.RegisterGenericWrapper(Optional[any]{}, "Value", "Set")
+//
+// Column values are set into valueField and presenceField is set to true. NULL columns leave the wrapper zero.
+func RegisterGenericWrapper(example interface{}, valueField, presenceField string) error {
+	wrapperType := reflect.TypeOf(example)
+	if wrapperType == nil || wrapperType.Kind() != reflect.Struct {
+		return errors.New("generic wrapper must be a struct")
+	}
+	if _, exists := wrapperType.FieldByName(valueField); !exists {
+		return errors.New(fmt.Sprintf("generic wrapper %s has no field %s", wrapperType, valueField))
+	}
+	if field, exists := wrapperType.FieldByName(presenceField); !exists || field.Type.Kind() != reflect.Bool {
+		return errors.New(fmt.Sprintf("generic wrapper %s has no bool field %s", wrapperType, presenceField))
+	}
+
+	genericWrappers.Store(newGenericWrapperKey(wrapperType), genericWrapper{valueField: valueField, presenceField: presenceField})
+	return nil
+}
+
+func newGenericWrapperKey(t reflect.Type) genericWrapperKey {
+	name, _, _ := strings.Cut(t.Name(), "[")
+	return genericWrapperKey{pkgPath: t.PkgPath(), name: name}
+}
+
+func getGenericWrapper(t reflect.Type) (genericWrapper, bool) {
+	if t.Kind() != reflect.Struct || !strings.Contains(t.Name(), "[") {
+		return genericWrapper{}, false
+	}
+	wrapper, exists := genericWrappers.Load(newGenericWrapperKey(t))
+	if !exists {
+		return genericWrapper{}, false
+	}
+	return wrapper.(genericWrapper), true
+}
+
+// setGenericWrapperField sets the value into the value field of the wrapper and marks it present
+func setGenericWrapperField(field reflect.Value, wrapper genericWrapper, value interface{}) error {
+	if err := setFieldValue(field.FieldByName(wrapper.valueField), value); err != nil {
+		return err
+	}
+	field.FieldByName(wrapper.presenceField).SetBool(true)
+	return nil
+}
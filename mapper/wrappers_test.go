@@ -0,0 +1,45 @@
+package mapper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+func TestScanOneWithGenericWrapper(t *testing.T) {
+	type profile struct {
+		ProfileId uint             `primaryKey:"profile_id"`
+		Age       Optional[int]    `db:"age"`
+		Nickname  Optional[string] `db:"nickname"`
+		Bio       Optional[string] `db:"bio"`
+	}
+	err := RegisterGenericWrapper(Optional[any]{}, "Value", "Set")
+	assert.NoError(t, err)
+	defer genericWrappers.Delete(newGenericWrapperKey(reflect.TypeOf(Optional[any]{})))
+	mock := setupPostgresMock(t, "^SELECT (.+) FROM profiles$",
+		[][]interface{}{{1, int64(42), "neo", nil}},
+		[]string{"profile_id", "age", "nickname", "bio"})
+	rows, err := mock.Query(context.Background(), "SELECT * FROM profiles")
+	assert.NoError(t, err)
+
+	var result profile
+	err = ScanOne(rows, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Optional[int]{Value: 42, Set: true}, result.Age)
+	assert.Equal(t, Optional[string]{Value: "neo", Set: true}, result.Nickname)
+	assert.Equal(t, Optional[string]{}, result.Bio)
+}
+
+func TestRegisterGenericWrapperValidatesFields(t *testing.T) {
+	err := RegisterGenericWrapper(Optional[any]{}, "Value", "Valid")
+
+	assert.EqualError(t, err, "generic wrapper mapper.Optional[interface {}] has no bool field Valid")
+}
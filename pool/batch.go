@@ -0,0 +1,67 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/raunlo/pgx-with-automapper/mapper"
+	reflectutils "github.com/raunlo/pgx-with-automapper/reflect_utils"
+)
+
+// batchSender sends the batch of queryBatch on the pool or in the transaction
+type batchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// queryBatch sends the batch and maps result of each queued query into the dest of the same position. Slices are
+// scanned like QueryList, structs like QueryOne and other values like QueryScalar. Results of queries with nil dest,
+// e.g. inserts, are discarded.
+func queryBatch(ctx context.Context, sender batchSender, batch *pgx.Batch, dests ...interface{}) (err error) {
+	if batch.Len() != len(dests) {
+		return errors.New(fmt.Sprintf("batch has %d queries, but %d destinations", batch.Len(), len(dests)))
+	}
+
+	results := sender.SendBatch(ctx, batch)
+	defer func() {
+		if closeErr := results.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	for i, dest := range dests {
+		if err := scanBatchResult(ctx, results, dest); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("batch query %d", i))
+		}
+	}
+	return nil
+}
+
+func scanBatchResult(ctx context.Context, results pgx.BatchResults, dest interface{}) error {
+	if dest == nil {
+		_, err := results.Exec()
+		return err
+	}
+
+	rows, err := results.Query()
+	if err != nil {
+		return err
+	}
+	return scanRows(ctx, rows, dest)
+}
+
+// scanRows maps rows into dest by its type: slices like QueryList, structs like QueryOne and other values like
+// QueryScalar
+func scanRows(ctx context.Context, rows pgx.Rows, dest interface{}) error {
+	destinationType := reflectutils.DeReferencePointer(reflect.TypeOf(dest))
+	switch {
+	case destinationType.Kind() == reflect.Slice:
+		return mapper.ScanManyContext(ctx, rows, dest)
+	case !mapper.IsScalarType(destinationType):
+		return mapper.ScanOneContext(ctx, rows, dest)
+	default:
+		return mapper.ScanScalar(rows, dest)
+	}
+}
@@ -0,0 +1,36 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBatchMapsEachResult(t *testing.T) {
+	batch := &pgx.Batch{}
+	batch.Queue("SELECT * FROM users WHERE id = $1", 1)
+	batch.Queue("SELECT * FROM users ORDER BY id")
+	batch.Queue("SELECT COUNT(*) FROM users")
+	batch.Queue("SELECT 1")
+
+	var user testUserStruct
+	var users []testUserStruct
+	var count int
+	err := connectionPool.QueryBatch(context.Background(), batch, &user, &users, &count, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", user.Name)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, 1, count)
+}
+
+func TestQueryBatchRequiresDestinationForEachQuery(t *testing.T) {
+	batch := &pgx.Batch{}
+	batch.Queue("SELECT * FROM users")
+
+	err := connectionPool.QueryBatch(context.Background(), batch)
+
+	assert.EqualError(t, err, "batch has 1 queries, but 0 destinations")
+}
@@ -0,0 +1,110 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/raunlo/pgx-with-automapper/mapper"
+)
+
+// maxQueryParameters is the limit of bind parameters Postgres accepts in a single statement
+const maxQueryParameters = 65535
+
+// Executor runs statements. It is implemented by both Conn and TransactionWrapper.
+type Executor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// BulkUpsert inserts rows into the table using multi-row INSERT statements. Rows conflicting on conflictKey column
+// are updated with the new values of all other columns. Columns generated by the database are omitted like in
+// mapper.InsertColumns, except for the conflict key. Table may be schema qualified, e.g. public.products. Returns
+// number of inserted or updated rows.
+func BulkUpsert[T any](ctx context.Context, c Executor, table string, rows []T, conflictKey string) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+	columns, err := mapper.Columns(entityType)
+	if err != nil {
+		return 0, err
+	}
+	if !containsColumn(columns, conflictKey) {
+		return 0, errors.New(fmt.Sprintf("conflict key %s is not a mapped column", conflictKey))
+	}
+	insertColumns, err := mapper.InsertColumns(entityType)
+	if err != nil {
+		return 0, err
+	}
+	columns = slices.DeleteFunc(columns, func(column string) bool {
+		return column != conflictKey && !containsColumn(insertColumns, column)
+	})
+
+	chunkSize := maxQueryParameters / len(columns)
+	var affectedRows int64
+	for start := 0; start < len(rows); start += chunkSize {
+		end := min(start+chunkSize, len(rows))
+		sql, args, err := buildBulkUpsert(table, columns, conflictKey, rows[start:end])
+		if err != nil {
+			return affectedRows, err
+		}
+
+		commandTag, err := c.Exec(ctx, sql, args...)
+		if err != nil {
+			return affectedRows, errors.Wrap(err, "bulk upsert")
+		}
+		affectedRows += commandTag.RowsAffected()
+	}
+	return affectedRows, nil
+}
+
+func buildBulkUpsert[T any](table string, columns []string, conflictKey string, rows []T) (string, []any, error) {
+	var sql strings.Builder
+	args := make([]any, 0, len(rows)*len(columns))
+
+	quotedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = pgx.Identifier{column}.Sanitize()
+	}
+
+	fmt.Fprintf(&sql, "INSERT INTO %s (%s) VALUES ",
+		mapper.SanitizeTable(table), strings.Join(quotedColumns, ", "))
+	for rowIndex, row := range rows {
+		values, err := mapper.BindStruct(row)
+		if err != nil {
+			return "", nil, err
+		}
+
+		placeholders := make([]string, len(columns))
+		for columnIndex, column := range columns {
+			args = append(args, values[column])
+			placeholders[columnIndex] = fmt.Sprintf("$%d", len(args))
+		}
+		if rowIndex > 0 {
+			sql.WriteString(", ")
+		}
+		fmt.Fprintf(&sql, "(%s)", strings.Join(placeholders, ", "))
+	}
+
+	onConflict, err := mapper.BuildOnConflict(reflect.TypeOf((*T)(nil)).Elem(), columns, []string{conflictKey})
+	if err != nil {
+		return "", nil, err
+	}
+	sql.WriteString(" " + onConflict)
+	return sql.String(), args, nil
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProductStruct struct {
+	ProductId int    `primaryKey:"id"`
+	Name      string `db:"name"`
+	Stock     int    `db:"stock"`
+}
+
+func TestBulkUpsertInsertsAndUpdatesRows(t *testing.T) {
+	ctx := context.Background()
+	_, err := connectionPool.Exec(ctx, `
+        CREATE TABLE products (
+            id INT PRIMARY KEY,
+            name VARCHAR(255) NOT NULL,
+            stock INT NOT NULL
+        );
+        INSERT INTO products (id, name, stock) VALUES (1, 'Chair', 1);
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create products table: %v", err)
+	}
+
+	affectedRows, err := BulkUpsert(ctx, connectionPool, "products", []testProductStruct{
+		{ProductId: 1, Name: "Chair", Stock: 5},
+		{ProductId: 2, Name: "Table", Stock: 2},
+	}, "id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affectedRows)
+
+	var res []testProductStruct
+	err = connectionPool.QueryList(ctx, "SELECT * FROM products ORDER BY id", &res, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []testProductStruct{{ProductId: 1, Name: "Chair", Stock: 5}, {ProductId: 2, Name: "Table", Stock: 2}}, res)
+}
+
+func TestBulkUpsertRejectsUnknownConflictKey(t *testing.T) {
+	_, err := BulkUpsert(context.Background(), connectionPool, "products", []testProductStruct{{ProductId: 3}}, "sku")
+
+	assert.EqualError(t, err, "conflict key sku is not a mapped column")
+}
+
+type testBulkOrderStruct struct {
+	OrderId   int    `primaryKey:"id"`
+	Sku       string `db:"sku,auto"`
+	Group     string `db:"group"`
+	CreatedAt string `db:"created_at,auto"`
+}
+
+func TestBulkUpsertOmitsGeneratedColumnsAndQuotesIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	_, err := connectionPool.Exec(ctx, `
+        CREATE TABLE bulk_orders (
+            id INT PRIMARY KEY,
+            sku TEXT UNIQUE DEFAULT gen_random_uuid()::text,
+            "group" VARCHAR(255) NOT NULL,
+            created_at TEXT GENERATED ALWAYS AS ('order-' || id) STORED
+        );
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create bulk_orders table: %v", err)
+	}
+
+	affectedRows, err := BulkUpsert(ctx, connectionPool, "public.bulk_orders", []testBulkOrderStruct{
+		{OrderId: 1, Sku: "A-1", Group: "retail"},
+	}, "sku")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affectedRows)
+
+	affectedRows, err = BulkUpsert(ctx, connectionPool, "bulk_orders", []testBulkOrderStruct{
+		{OrderId: 1, Sku: "A-1", Group: "wholesale"},
+	}, "sku")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affectedRows)
+
+	var res []testBulkOrderStruct
+	err = connectionPool.QueryList(ctx, "SELECT * FROM bulk_orders", &res)
+	assert.NoError(t, err)
+	assert.Equal(t, []testBulkOrderStruct{{OrderId: 1, Sku: "A-1", Group: "wholesale", CreatedAt: "order-1"}}, res)
+}
@@ -2,9 +2,13 @@ package pool
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"net"
 	"net/url"
+	"reflect"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -12,12 +16,20 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 	"github.com/raunlo/pgx-with-automapper/mapper"
+	reflectutils "github.com/raunlo/pgx-with-automapper/reflect_utils"
+)
+
+const (
+	defaultConnectRetryBackoff = 500 * time.Millisecond
+	defaultConnTimeout         = 5 * time.Second
 )
 
 type DatabaseConfiguration struct {
-	MaxOpenConns             *int           `yaml:"maxOpenConns"`
-	MinOpenConns             *int           `yaml:"minOpenConns"`
-	StatementCacheCapacity   *int           `yaml:"statementCacheCapacity"`
+	MaxOpenConns           *int `yaml:"maxOpenConns"`
+	MinOpenConns           *int `yaml:"minOpenConns"`
+	StatementCacheCapacity *int `yaml:"statementCacheCapacity"`
+	// ConnTimeout limits each attempt of connecting to the database on startup. Defaults to 5s. Timeouts of queries
+	// come from the context given to them by the caller.
 	ConnTimeout              *time.Duration `yaml:"connTimeout"`
 	MaxOpenConnTTL           *time.Duration `yaml:"maxOpenConnTTL"`
 	MaxIdleConnTTL           *time.Duration `yaml:"maxIdleConnTTL"`
@@ -29,6 +41,21 @@ type DatabaseConfiguration struct {
 	Name                     *string        `yaml:"name"`
 	Schema                   *string        `yaml:"schema"`
 	Sslmode                  *string        `yaml:"sslMode"`
+	// ConnectRetries is how many times connecting is retried when the database is not reachable on startup
+	ConnectRetries *int `yaml:"connectRetries"`
+	// ConnectRetryBackoff is the wait before the first connect retry, doubled after each retry. Defaults to 500ms.
+	ConnectRetryBackoff *time.Duration `yaml:"connectRetryBackoff"`
+	// ReplicaHosts are hosts of read replicas, either host or host:port. Replicas use Port when port is not given.
+	ReplicaHosts []string `yaml:"replicaHosts"`
+	// FatalErrorRetries is how many times queries run on the pool are retried after failing with one of
+	// FatalSQLStates. Exec may write, so it is retried only when pgconn.SafeToRetry reports the statement was never
+	// sent, whatever the error. Queries in transactions are never retried. Retrying is disabled when it is not set.
+	FatalErrorRetries *int `yaml:"fatalErrorRetries"`
+	// FatalSQLStates are SQLSTATE codes of errors to retry, admin shutdown (57P01) by default
+	FatalSQLStates []string `yaml:"fatalSqlStates"`
+	// DefaultTxOptions are options of transactions started with BeginTxDefault, e.g. serializable isolation level.
+	// Transactions have the database defaults when it is not set.
+	DefaultTxOptions *pgx.TxOptions `yaml:"defaultTxOptions"`
 }
 
 func (cfg DatabaseConfiguration) getDSN() string { // nolint:gocritic
@@ -45,8 +72,9 @@ func (cfg DatabaseConfiguration) getDSN() string { // nolint:gocritic
 	if cfg.MaxIdleConnTTL != nil {
 		query.Set("pool_max_conn_idle_time", cfg.MaxIdleConnTTL.String())
 	}
-	if cfg.MaxIdleConnTTL != nil {
-		query.Set("pool_max_conn_idle_time", cfg.MaxIdleConnTTL.String())
+	if cfg.ConnTimeout != nil {
+		// connect_timeout is whole seconds, so partial seconds are rounded up
+		query.Set("connect_timeout", strconv.FormatInt(int64(math.Ceil(cfg.ConnTimeout.Seconds())), 10))
 	}
 	if cfg.MaxConnLifetimeJitterTTL != nil {
 		query.Set("pool_max_conn_lifetime_jitter", cfg.MaxConnLifetimeJitterTTL.String())
@@ -71,27 +99,163 @@ func (cfg DatabaseConfiguration) getDSN() string { // nolint:gocritic
 	return dsn.String()
 }
 
+// replicaConfiguration returns configuration of the replica at host, which is either host or host:port
+func (cfg DatabaseConfiguration) replicaConfiguration(host string) DatabaseConfiguration { // nolint:gocritic
+	replicaHost, replicaPort, err := net.SplitHostPort(host)
+	if err != nil {
+		replicaHost, replicaPort = host, *cfg.Port
+	}
+	cfg.Host = &replicaHost
+	cfg.Port = &replicaPort
+	return cfg
+}
+
 type Conn interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-	QueryOne(ctx context.Context, sql string, dest interface{}, args pgx.NamedArgs) error
-	QueryList(ctx context.Context, sql string, dest interface{}, args pgx.NamedArgs) error
+	// QueryOne maps result of the query into dest struct. Args are passed to pgx as is, so they may be pgx.NamedArgs,
+	// other pgx.QueryRewriter or positional arguments for $1, $2, ... placeholders. Single nil argument means no
+	// arguments.
+	QueryOne(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryList maps result of the query into dest slice. Args are passed like in QueryOne.
+	QueryList(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryOneArgs is like QueryOne, but takes positional arguments for $1, $2, ... placeholders
+	//
+	// Deprecated: QueryOne takes positional arguments as well.
+	QueryOneArgs(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryListArgs is like QueryList, but takes positional arguments for $1, $2, ... placeholders
+	//
+	// Deprecated: QueryList takes positional arguments as well.
+	QueryListArgs(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryScalar scans the only column of the only row into dest, e.g. *int for COUNT(*). Returns mapper.ErrNoRows
+	// when there are no rows. Args are passed like in QueryOne.
+	QueryScalar(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryRowStruct maps the first row of the query into dest struct, ignoring the rest like LIMIT 1 would. Returns
+	// mapper.ErrNoRows when there are no rows. Args are passed like in QueryOne.
+	QueryRowStruct(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryBatch sends the queued queries in one round trip and maps result of each query into dest of the same
+	// position: slices like QueryList, structs like QueryOne and other values like QueryScalar. Use nil dest for
+	// queries without result.
+	QueryBatch(ctx context.Context, batch *pgx.Batch, dests ...interface{}) error
+	// CopyFromStructs copies slice of entities into the table with COPY protocol, using columns of their db tags
+	CopyFromStructs(ctx context.Context, tableName pgx.Identifier, src interface{}) (int64, error)
+	// QueryOneReadOnly is like QueryOne, but runs the query on a read replica. Replicas are used in round-robin
+	// order and the query runs on the primary when no replicas are configured.
+	QueryOneReadOnly(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryListReadOnly is like QueryList, but runs the query on a read replica like QueryOneReadOnly
+	QueryListReadOnly(ctx context.Context, sql string, dest interface{}, args ...any) error
 	Ping(ctx context.Context) error
 	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (TransactionWrapper, error)
+	// BeginTxDefault starts a transaction with DefaultTxOptions of the configuration
+	BeginTxDefault(ctx context.Context) (TransactionWrapper, error)
+	// RunInTx runs fn in a transaction, which is committed when fn returns nil and rolled back when fn returns an
+	// error or panics. Error of fn is returned as is, so it can be matched with errors.Is.
+	RunInTx(ctx context.Context, txOptions pgx.TxOptions, fn func(tx TransactionWrapper) error) error
+	// Stats returns statistics of the underlying connection pool, e.g. for exporting acquired and idle connections
+	Stats() *pgxpool.Stat
+	// ExecReturning runs statement with RETURNING clause, maps the returned rows into dest like QueryBatch and returns
+	// the number of affected rows. Use nil dest to only count the affected rows.
+	ExecReturning(ctx context.Context, sql string, dest interface{}, args ...any) (int64, error)
+	// QueryInSchema runs the query with search_path set to the schema only for this query, e.g. for routing requests
+	// of tenants into their schemas, and maps the result into dest like QueryBatch. Use nil dest for statements
+	// without result.
+	QueryInSchema(ctx context.Context, schema string, sql string, dest interface{}, args ...any) error
+	// Acquire pins a connection of the pool for session scoped work, e.g. advisory locks or temporary tables. The
+	// connection must be returned to the pool with Release.
+	Acquire(ctx context.Context) (*pgxpool.Conn, error)
+	// HealthCheck acquires a connection and runs SELECT 1 on it, e.g. for readiness probes. Unlike Ping, it fails
+	// when no connection can be acquired from an exhausted pool before ctx is done.
+	HealthCheck(ctx context.Context) error
+	// Explain returns the plan of the statement without executing it, e.g. to verify index usage of statements built
+	// by mapper.BuildInsert. Args are passed like in QueryOne.
+	Explain(ctx context.Context, sql string, args ...any) (string, error)
 }
 
 func NewDatabasePool(cfg DatabaseConfiguration) Conn {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	pool, err := pgxpool.New(ctx, cfg.getDSN())
+	return NewDatabasePoolWithConfig(cfg)
+}
+
+// NewDatabasePoolWithConfig creates a pool like NewDatabasePool and applies options to the pgxpool configuration of
+// the primary and replica pools, e.g. WithQueryTracer.
+func NewDatabasePoolWithConfig(cfg DatabaseConfiguration, opts ...Option) Conn {
+	primary := newPool(cfg, opts)
+
+	replicas := make([]*pgxpool.Pool, 0, len(cfg.ReplicaHosts))
+	for _, host := range cfg.ReplicaHosts {
+		replicas = append(replicas, newPool(cfg.replicaConfiguration(host), opts))
+	}
+	pool := &databaseConnectionPool{pool: primary, replicas: replicas, retry: newRetryPolicy(cfg)}
+	if cfg.DefaultTxOptions != nil {
+		pool.defaultTxOptions = *cfg.DefaultTxOptions
+	}
+	return pool
+}
+
+// NewDatabasePoolFromPool wraps an existing pool, e.g. one configured with tracing or a custom dialer, so queries on
+// it are automapped. The caller owns the pool and is responsible for closing it.
+func NewDatabasePoolFromPool(pool *pgxpool.Pool) Conn {
+	return &databaseConnectionPool{pool: pool, retry: newRetryPolicy(DatabaseConfiguration{})}
+}
+
+// NewDatabasePoolFromDSN creates a pool from connection string, e.g. DATABASE_URL in URL or key=value format.
+// Pool options can be given as pool_max_conns and other pgxpool parameters of the connection string.
+func NewDatabasePoolFromDSN(ctx context.Context, dsn string) (Conn, error) {
+	pool, err := pgxpool.New(ctx, dsn)
 	if err != nil {
-		panic(errors.Wrap(err, "create db conn pool"))
+		return nil, errors.Wrap(err, "create db conn pool")
 	}
 	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, errors.Wrap(err, "Could not ping db")
+	}
+	return &databaseConnectionPool{pool: pool, retry: newRetryPolicy(DatabaseConfiguration{})}, nil
+}
+
+func newPool(cfg DatabaseConfiguration, opts []Option) *pgxpool.Pool { // nolint:gocritic
+	poolConfig, err := pgxpool.ParseConfig(cfg.getDSN())
+	if err != nil {
+		panic(errors.Wrap(err, "create db conn pool"))
+	}
+	for _, opt := range opts {
+		opt(poolConfig)
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		panic(errors.Wrap(err, "create db conn pool"))
+	}
+	if err := connectWithRetry(cfg, pool.Ping); err != nil {
 		panic(errors.Wrap(err, "Could not ping db"))
 	}
-	return &databaseConnectionPool{pool: pool}
+	return pool
+}
+
+// connectWithRetry pings the database until it succeeds or ConnectRetries run out. Each attempt is limited by
+// ConnTimeout. Wait between the attempts starts from ConnectRetryBackoff and doubles after each attempt.
+func connectWithRetry(cfg DatabaseConfiguration, ping func(ctx context.Context) error) error { // nolint:gocritic
+	retries := 0
+	if cfg.ConnectRetries != nil {
+		retries = *cfg.ConnectRetries
+	}
+	backoff := defaultConnectRetryBackoff
+	if cfg.ConnectRetryBackoff != nil {
+		backoff = *cfg.ConnectRetryBackoff
+	}
+	timeout := defaultConnTimeout
+	if cfg.ConnTimeout != nil {
+		timeout = *cfg.ConnTimeout
+	}
+
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := ping(ctx)
+		cancel()
+		if err == nil || attempt >= retries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
 }
 
 // wrapper around transactions. To include twi emthods QueryOne and QueryList, which automap results.
@@ -113,6 +277,8 @@ type TransactionWrapper interface {
 	Rollback(ctx context.Context) error
 
 	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	// CopyFromStructs copies slice of entities into the table with COPY protocol, using columns of their db tags
+	CopyFromStructs(ctx context.Context, tableName pgx.Identifier, src interface{}) (int64, error)
 	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
 	LargeObjects() pgx.LargeObjects
 
@@ -125,10 +291,28 @@ type TransactionWrapper interface {
 	// Conn returns the underlying *Conn that on which this transaction is executing.
 	Conn() *pgx.Conn
 
-	// QueryOne Query one and map it into struct
-	QueryOne(ctx context.Context, sql string, dest interface{}, args pgx.NamedArgs) error
-	// QueryList Query list and map it into list of structs
-	QueryList(ctx context.Context, sql string, dest interface{}, args pgx.NamedArgs) error
+	// QueryOne Query one and map it into struct. Args may be pgx.NamedArgs, other pgx.QueryRewriter or positional
+	QueryOne(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryList Query list and map it into list of structs. Args are passed like in QueryOne.
+	QueryList(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryOneArgs is like QueryOne, but takes positional arguments for $1, $2, ... placeholders
+	//
+	// Deprecated: QueryOne takes positional arguments as well.
+	QueryOneArgs(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryListArgs is like QueryList, but takes positional arguments for $1, $2, ... placeholders
+	//
+	// Deprecated: QueryList takes positional arguments as well.
+	QueryListArgs(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryScalar Query single value like COUNT(*) into primitive pointer
+	QueryScalar(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryRowStruct Query first row into struct pointer
+	QueryRowStruct(ctx context.Context, sql string, dest interface{}, args ...any) error
+	// QueryBatch Send batch of queries and map each result into dest of the same position
+	QueryBatch(ctx context.Context, batch *pgx.Batch, dests ...interface{}) error
+	// ExecReturning Run statement with RETURNING clause, map returned rows into dest and return affected rows
+	ExecReturning(ctx context.Context, sql string, dest interface{}, args ...any) (int64, error)
+	// Explain Return plan of the statement without executing it
+	Explain(ctx context.Context, sql string, args ...any) (string, error)
 }
 
 type transactionWrapper struct {
@@ -151,6 +335,10 @@ func (t *transactionWrapper) CopyFrom(ctx context.Context, tableName pgx.Identif
 	return t.tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
 }
 
+func (t *transactionWrapper) CopyFromStructs(ctx context.Context, tableName pgx.Identifier, src interface{}) (int64, error) {
+	return copyFromStructs(ctx, t.tx, tableName, src)
+}
+
 func (t *transactionWrapper) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
 	return t.tx.SendBatch(ctx, b)
 }
@@ -179,64 +367,243 @@ func (t *transactionWrapper) Conn() *pgx.Conn {
 	return t.tx.Conn()
 }
 
-func (t *transactionWrapper) QueryOne(ctx context.Context, sql string, dest interface{}, args pgx.NamedArgs) error {
-	rows, err := t.Query(ctx, sql, args)
+func (t *transactionWrapper) QueryOne(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return queryOne(ctx, t.tx, sql, dest, queryArgs(args)...)
+}
+
+func (t *transactionWrapper) QueryList(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return queryList(ctx, t.tx, sql, dest, queryArgs(args)...)
+}
+
+func (t *transactionWrapper) QueryOneArgs(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return queryOne(ctx, t.tx, sql, dest, args...)
+}
+
+func (t *transactionWrapper) QueryListArgs(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return queryList(ctx, t.tx, sql, dest, args...)
+}
+
+func (t *transactionWrapper) QueryScalar(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return queryScalar(ctx, t.tx, sql, dest, queryArgs(args)...)
+}
+
+func (t *transactionWrapper) QueryRowStruct(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return queryRowStruct(ctx, t.tx, sql, dest, queryArgs(args)...)
+}
+
+func (t *transactionWrapper) ExecReturning(ctx context.Context, sql string, dest interface{}, args ...any) (int64, error) {
+	return execReturning(ctx, t.tx, sql, dest, queryArgs(args)...)
+}
+
+func (t *transactionWrapper) Explain(ctx context.Context, sql string, args ...any) (string, error) {
+	return explain(ctx, t.tx, sql, queryArgs(args)...)
+}
+
+func (t *transactionWrapper) QueryBatch(ctx context.Context, batch *pgx.Batch, dests ...interface{}) error {
+	return queryBatch(ctx, t.tx, batch, dests...)
+}
+
+// querier runs the queries of the mapping helpers on the pool or in the transaction
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// queryArgs returns query arguments without single nil argument, which callers pass for queries without arguments,
+// e.g. QueryOne(ctx, sql, &dest, nil), and which would be sent as a bind parameter otherwise
+func queryArgs(args []any) []any {
+	if len(args) != 1 {
+		return args
+	}
+	if args[0] == nil {
+		return nil
+	}
+	if named, ok := args[0].(pgx.NamedArgs); ok && named == nil {
+		return nil
+	}
+	return args
+}
+
+// queryOne runs the query and maps the result into dest
+func queryOne(ctx context.Context, q querier, sql string, dest interface{}, args ...any) error {
+	rows, err := q.Query(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	return mapper.ScanOne(rows, dest)
+	return mapper.ScanOneContext(ctx, rows, dest)
 }
 
-func (t *transactionWrapper) QueryList(ctx context.Context, sql string, dest interface{}, args pgx.NamedArgs) error {
-	rows, err := t.Query(ctx, sql, args)
+// queryScalar runs the query and scans its single value into dest
+func queryScalar(ctx context.Context, q querier, sql string, dest interface{}, args ...any) error {
+	rows, err := q.Query(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	return mapper.ScanMany(rows, dest)
+	return mapper.ScanScalar(rows, dest)
 }
 
-type databaseConnectionPool struct {
-	pool *pgxpool.Pool
-}
+// queryRowStruct runs the query and maps its first row into dest
+func queryRowStruct(ctx context.Context, q querier, sql string, dest interface{}, args ...any) error {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
-func (p *databaseConnectionPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
-	return p.pool.QueryRow(ctx, sql, args...)
+	return mapper.ScanFirst(rows, dest)
 }
 
-func (p *databaseConnectionPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
-	return p.pool.Query(ctx, sql, args...)
+// execReturning runs the statement, maps its returned rows into dest and returns the number of affected rows
+func execReturning(ctx context.Context, q querier, sql string, dest interface{}, args ...any) (int64, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	if dest == nil {
+		// statement without mapped result, like QueryBatch with nil dest, only needs its rows closed
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+	} else if err := scanRows(ctx, rows, dest); err != nil {
+		return 0, err
+	}
+	// command tag is available once the rows are closed, which scanning does
+	return rows.CommandTag().RowsAffected(), nil
 }
 
-func (p *databaseConnectionPool) QueryOne(ctx context.Context, sql string, dest interface{}, args pgx.NamedArgs) error {
-	rows, err := p.pool.Query(ctx, sql, args)
+// queryList runs the query and maps the result into dest slice
+func queryList(ctx context.Context, q querier, sql string, dest interface{}, args ...any) error {
+	if destinationType := reflect.TypeOf(dest); destinationType == nil || destinationType.Kind() != reflect.Ptr ||
+		reflectutils.DeReferencePointer(destinationType).Kind() != reflect.Slice {
+		return errors.New(fmt.Sprintf("QueryList requires dest to be a pointer to a slice, got %T (sql: %s)", dest, sql))
+	}
+	rows, err := q.Query(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	return mapper.ScanOne(rows, dest)
+	return mapper.ScanManyContext(ctx, rows, dest)
 }
 
-func (p *databaseConnectionPool) QueryList(ctx context.Context, sql string, dest interface{}, args pgx.NamedArgs) error {
-	rows, err := p.pool.Query(ctx, sql, args)
-	if err != nil {
-		return err
+type databaseConnectionPool struct {
+	pool        *pgxpool.Pool
+	replicas    []*pgxpool.Pool
+	nextReplica atomic.Uint64
+	retry       retryPolicy
+	// defaultTxOptions are options of transactions started with BeginTxDefault
+	defaultTxOptions pgx.TxOptions
+}
+
+// readPool returns the next replica in round-robin order, or the primary when there are no replicas
+func (p *databaseConnectionPool) readPool() *pgxpool.Pool {
+	if len(p.replicas) == 0 {
+		return p.pool
 	}
-	defer rows.Close()
+	next := p.nextReplica.Add(1) - 1
+	return p.replicas[next%uint64(len(p.replicas))]
+}
+
+func (p *databaseConnectionPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return p.pool.QueryRow(ctx, sql, args...)
+}
+
+func (p *databaseConnectionPool) Query(ctx context.Context, sql string, args ...any) (rows pgx.Rows, err error) {
+	err = p.retry.do(ctx, func() error {
+		rows, err = p.pool.Query(ctx, sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (p *databaseConnectionPool) QueryOne(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return p.retry.doInto(ctx, dest, func() error { return queryOne(ctx, p.pool, sql, dest, queryArgs(args)...) })
+}
+
+func (p *databaseConnectionPool) QueryList(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return p.retry.doInto(ctx, dest, func() error { return queryList(ctx, p.pool, sql, dest, queryArgs(args)...) })
+}
+
+func (p *databaseConnectionPool) QueryOneArgs(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return p.retry.doInto(ctx, dest, func() error { return queryOne(ctx, p.pool, sql, dest, args...) })
+}
+
+func (p *databaseConnectionPool) QueryListArgs(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return p.retry.doInto(ctx, dest, func() error { return queryList(ctx, p.pool, sql, dest, args...) })
+}
+
+func (p *databaseConnectionPool) QueryScalar(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return p.retry.doInto(ctx, dest, func() error { return queryScalar(ctx, p.pool, sql, dest, queryArgs(args)...) })
+}
 
-	return mapper.ScanMany(rows, dest)
+func (p *databaseConnectionPool) QueryRowStruct(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return p.retry.doInto(ctx, dest, func() error { return queryRowStruct(ctx, p.pool, sql, dest, queryArgs(args)...) })
 }
 
-func (p *databaseConnectionPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
-	return p.pool.Exec(ctx, sql, args...)
+func (p *databaseConnectionPool) QueryBatch(ctx context.Context, batch *pgx.Batch, dests ...interface{}) error {
+	return queryBatch(ctx, p.pool, batch, dests...)
+}
+
+func (p *databaseConnectionPool) CopyFromStructs(ctx context.Context, tableName pgx.Identifier, src interface{}) (int64, error) {
+	return copyFromStructs(ctx, p.pool, tableName, src)
+}
+
+func (p *databaseConnectionPool) QueryOneReadOnly(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return p.retry.doInto(ctx, dest, func() error { return queryOne(ctx, p.readPool(), sql, dest, queryArgs(args)...) })
+}
+
+func (p *databaseConnectionPool) QueryListReadOnly(ctx context.Context, sql string, dest interface{}, args ...any) error {
+	return p.retry.doInto(ctx, dest, func() error { return queryList(ctx, p.readPool(), sql, dest, queryArgs(args)...) })
+}
+
+func (p *databaseConnectionPool) Exec(ctx context.Context, sql string, args ...any) (tag pgconn.CommandTag, err error) {
+	err = p.retry.doUnsent(ctx, func() error {
+		tag, err = p.pool.Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, err
 }
 
 func (p *databaseConnectionPool) Ping(ctx context.Context) error { return p.pool.Ping(ctx) }
 
+func (p *databaseConnectionPool) Stats() *pgxpool.Stat { return p.pool.Stat() }
+
+func (p *databaseConnectionPool) ExecReturning(ctx context.Context, sql string, dest interface{}, args ...any) (int64, error) {
+	return execReturning(ctx, p.pool, sql, dest, queryArgs(args)...)
+}
+
+func (p *databaseConnectionPool) QueryInSchema(ctx context.Context, schema string, sql string, dest interface{}, args ...any) error {
+	return queryInSchema(ctx, p.pool, schema, sql, dest, queryArgs(args)...)
+}
+
+func (p *databaseConnectionPool) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	return p.pool.Acquire(ctx)
+}
+
+func (p *databaseConnectionPool) Explain(ctx context.Context, sql string, args ...any) (string, error) {
+	return explain(ctx, p.pool, sql, queryArgs(args)...)
+}
+
+func (p *databaseConnectionPool) HealthCheck(ctx context.Context) error {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		stat := p.pool.Stat()
+		return errors.Wrapf(err, "health check: acquire connection (%d of %d connections in use)",
+			stat.AcquiredConns(), stat.MaxConns())
+	}
+	defer conn.Release()
+
+	var result int
+	if err := conn.QueryRow(ctx, "SELECT 1").Scan(&result); err != nil {
+		return errors.Wrap(err, "health check: run SELECT 1")
+	}
+	return nil
+}
+
 func (p *databaseConnectionPool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (TransactionWrapper, error) {
 	tx, err := p.pool.BeginTx(ctx, txOptions)
 	if err != nil {
@@ -244,3 +611,26 @@ func (p *databaseConnectionPool) BeginTx(ctx context.Context, txOptions pgx.TxOp
 	}
 	return &transactionWrapper{tx: tx}, nil
 }
+
+func (p *databaseConnectionPool) BeginTxDefault(ctx context.Context) (TransactionWrapper, error) {
+	return p.BeginTx(ctx, p.defaultTxOptions)
+}
+
+func (p *databaseConnectionPool) RunInTx(ctx context.Context, txOptions pgx.TxOptions, fn func(tx TransactionWrapper) error) (err error) {
+	tx, err := p.BeginTx(ctx, txOptions)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = tx.Rollback(ctx)
+			panic(recovered)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	return errors.Wrap(tx.Commit(ctx), "commit transaction")
+}
@@ -2,7 +2,10 @@ package pool
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/raunlo/pgx-with-automapper/mapper"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
@@ -168,6 +171,205 @@ func TestQueryListReturnsEmptyList(t *testing.T) {
 	assert.Equal(t, 0, len(res))
 }
 
+func TestQueryOneArgsWithPositionalArgs(t *testing.T) {
+	res := testUserStruct{}
+	err := connectionPool.QueryOneArgs(context.Background(), "SELECT * FROM users WHERE id = $1 AND name = $2", &res, 1, "John Doe")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), res.UserId)
+	assert.Equal(t, "john.doe@example.com", res.Email)
+}
+
+func TestQueryListArgsInTransactionWithPositionalArgs(t *testing.T) {
+	var res []testUserStruct
+	tx, err := connectionPool.BeginTx(context.Background(), pgx.TxOptions{})
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	err = tx.QueryListArgs(context.Background(), "SELECT * FROM users WHERE name LIKE $1", &res, "John%")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, "John Doe", res[0].Name)
+}
+
+func TestQueryOneWithNamedArgs(t *testing.T) {
+	res := testUserStruct{}
+	err := connectionPool.QueryOne(context.Background(), "SELECT * FROM users WHERE id = @id", &res, pgx.NamedArgs{"id": 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", res.Name)
+}
+
+func TestQueryOneReadOnlyWithoutReplicasUsesPrimary(t *testing.T) {
+	res := testUserStruct{}
+	err := connectionPool.QueryOneReadOnly(context.Background(), "SELECT * FROM users WHERE id = @id", &res, pgx.NamedArgs{"id": 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", res.Name)
+}
+
+func TestReadPoolRoundRobinsReplicas(t *testing.T) {
+	primary, first, second := &pgxpool.Pool{}, &pgxpool.Pool{}, &pgxpool.Pool{}
+	p := &databaseConnectionPool{pool: primary, replicas: []*pgxpool.Pool{first, second}}
+
+	assert.Same(t, first, p.readPool())
+	assert.Same(t, second, p.readPool())
+	assert.Same(t, first, p.readPool())
+}
+
+func TestReplicaConfiguration(t *testing.T) {
+	host, port := "primary", "5432"
+	cfg := DatabaseConfiguration{Host: &host, Port: &port}
+
+	withPort := cfg.replicaConfiguration("replica-1:6432")
+	withoutPort := cfg.replicaConfiguration("replica-2")
+
+	assert.Equal(t, "replica-1", *withPort.Host)
+	assert.Equal(t, "6432", *withPort.Port)
+	assert.Equal(t, "replica-2", *withoutPort.Host)
+	assert.Equal(t, "5432", *withoutPort.Port)
+	assert.Equal(t, "primary", *cfg.Host)
+}
+
+func TestGetDSNSetsConnectTimeout(t *testing.T) {
+	user, password, host, port, name := "user", "secret", "localhost", "5432", "app"
+	connTimeout, maxIdleConnTTL := 1500*time.Millisecond, time.Minute
+	cfg := DatabaseConfiguration{User: &user, Password: &password, Host: &host, Port: &port, Name: &name,
+		ConnTimeout: &connTimeout, MaxIdleConnTTL: &maxIdleConnTTL}
+
+	poolConfig, err := pgxpool.ParseConfig(cfg.getDSN())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, poolConfig.ConnConfig.ConnectTimeout)
+	assert.Equal(t, time.Minute, poolConfig.MaxConnIdleTime)
+}
+
+func TestNewDatabasePoolFromPool(t *testing.T) {
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, createDatabaseConfiguration(ctx).getDSN())
+	assert.NoError(t, err)
+	defer pool.Close()
+	conn := NewDatabasePoolFromPool(pool)
+
+	res := testUserStruct{}
+	err = conn.QueryOne(ctx, "SELECT * FROM users WHERE id = @id", &res, pgx.NamedArgs{"id": 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", res.Name)
+	assert.Equal(t, pool.Stat().TotalConns(), conn.Stats().TotalConns())
+}
+
+func TestNewDatabasePoolFromDSN(t *testing.T) {
+	ctx := context.Background()
+	conn, err := NewDatabasePoolFromDSN(ctx, createDatabaseConfiguration(ctx).getDSN())
+	assert.NoError(t, err)
+
+	res := testUserStruct{}
+	err = conn.QueryOne(ctx, "SELECT * FROM users WHERE id = @id", &res, pgx.NamedArgs{"id": 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", res.Name)
+}
+
+func TestNewDatabasePoolFromDSNWithInvalidDSN(t *testing.T) {
+	conn, err := NewDatabasePoolFromDSN(context.Background(), "postgres://user@localhost:notaport/db")
+
+	assert.ErrorContains(t, err, "create db conn pool")
+	assert.Nil(t, conn)
+}
+
+func TestQueryListRejectsNonSliceDestination(t *testing.T) {
+	res := testUserStruct{}
+	err := connectionPool.QueryList(context.Background(), "SELECT * FROM users", &res, nil)
+
+	assert.EqualError(t, err, "QueryList requires dest to be a pointer to a slice, got *pool.testUserStruct (sql: SELECT * FROM users)")
+}
+
+func TestQueryScalarReturnsCount(t *testing.T) {
+	var count int
+	err := connectionPool.QueryScalar(context.Background(), "SELECT COUNT(*) FROM users", &count, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestQueryScalarIntoNullInt64(t *testing.T) {
+	var maxId sql.NullInt64
+	err := connectionPool.QueryScalar(context.Background(), "SELECT MAX(id) FROM users WHERE id < 0", &maxId)
+
+	assert.NoError(t, err)
+	assert.False(t, maxId.Valid)
+
+	err = connectionPool.QueryScalar(context.Background(), "SELECT MIN(id) FROM users", &maxId)
+	assert.NoError(t, err)
+	assert.Equal(t, sql.NullInt64{Int64: 1, Valid: true}, maxId)
+}
+
+func TestQueryScalarWhichReturnsEmpty(t *testing.T) {
+	var name string
+	err := connectionPool.QueryScalar(context.Background(), "SELECT name FROM users WHERE id = @id", &name, pgx.NamedArgs{"id": 2})
+
+	assert.ErrorIs(t, err, mapper.ErrNoRows)
+}
+
+func TestQueryScalarWithPositionalArgs(t *testing.T) {
+	var name string
+	err := connectionPool.QueryScalar(context.Background(), "SELECT name FROM users WHERE id = $1", &name, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", name)
+}
+
+func TestQueryListReadOnlyWithPositionalArgs(t *testing.T) {
+	var res []testUserStruct
+	err := connectionPool.QueryListReadOnly(context.Background(), "SELECT * FROM users WHERE id = $1", &res, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "John Doe", res[0].Name)
+}
+
+func TestQueryOneWithPositionalArgs(t *testing.T) {
+	var res testUserStruct
+	err := connectionPool.QueryOne(context.Background(), "SELECT * FROM users WHERE id = $1 AND name = $2", &res, 1, "John Doe")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), res.UserId)
+}
+
+func TestQueryArgs(t *testing.T) {
+	assert.Nil(t, queryArgs([]any{nil}))
+	assert.Nil(t, queryArgs([]any{pgx.NamedArgs(nil)}))
+	assert.Equal(t, []any{pgx.NamedArgs{"id": 1}}, queryArgs([]any{pgx.NamedArgs{"id": 1}}))
+	assert.Equal(t, []any{nil, 1}, queryArgs([]any{nil, 1}))
+}
+
+func TestQueryRowStructReturnsFirstRow(t *testing.T) {
+	var res testUserStruct
+	err := connectionPool.QueryRowStruct(context.Background(), "SELECT * FROM users WHERE id = $1", &res, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testUserStruct{UserId: 1, Name: "John Doe", Email: "john.doe@example.com"}, res)
+}
+
+func TestQueryRowStructWithNilArgs(t *testing.T) {
+	var res testUserStruct
+	err := connectionPool.QueryRowStruct(context.Background(), "SELECT * FROM users ORDER BY id", &res, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testUserStruct{UserId: 1, Name: "John Doe", Email: "john.doe@example.com"}, res)
+}
+
+func TestQueryRowStructWhichReturnsEmpty(t *testing.T) {
+	var res testUserStruct
+	err := connectionPool.QueryRowStruct(context.Background(), "SELECT * FROM users WHERE id = $1", &res, 2)
+
+	assert.ErrorIs(t, err, mapper.ErrNoRows)
+}
+
 func TestQueryReturnsRows(t *testing.T) {
 	rows, err := connectionPool.Query(context.Background(), "SELECT * FROM users")
 	assert.NoError(t, err)
@@ -180,3 +382,138 @@ func TestQueryReturnsRows(t *testing.T) {
 	assert.Equal(t, "John Doe", rowMap["name"])
 	assert.Equal(t, "john.doe@example.com", rowMap["email"])
 }
+
+func TestStatsReturnsPoolStatistics(t *testing.T) {
+	err := connectionPool.Ping(context.Background())
+	assert.NoError(t, err)
+
+	stats := connectionPool.Stats()
+
+	assert.GreaterOrEqual(t, stats.TotalConns(), int32(1))
+	assert.Equal(t, int32(0), stats.AcquiredConns())
+}
+
+func TestBeginTxDefaultUsesDefaultTxOptions(t *testing.T) {
+	ctx := context.Background()
+	cfg := createDatabaseConfiguration(ctx)
+	cfg.DefaultTxOptions = &pgx.TxOptions{IsoLevel: pgx.Serializable}
+	conn := NewDatabasePool(*cfg)
+
+	tx, err := conn.BeginTxDefault(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var isolationLevel string
+	err = tx.QueryScalar(ctx, "SHOW transaction_isolation", &isolationLevel, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "serializable", isolationLevel)
+}
+
+func TestRunInTxCommitsOnSuccess(t *testing.T) {
+	ctx := context.Background()
+
+	err := connectionPool.RunInTx(ctx, pgx.TxOptions{}, func(tx TransactionWrapper) error {
+		_, err := tx.Exec(ctx, "INSERT INTO users (id, name, email) VALUES (910, 'Committed', 'committed@example.com')")
+		return err
+	})
+
+	assert.NoError(t, err)
+	var count int
+	err = connectionPool.QueryScalar(ctx, "SELECT COUNT(*) FROM users WHERE id = 910", &count, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestRunInTxRollsBackOnErrorAndPanic(t *testing.T) {
+	ctx := context.Background()
+	insert := func(tx TransactionWrapper) {
+		_, err := tx.Exec(ctx, "INSERT INTO users (id, name, email) VALUES (911, 'Rolled Back', 'rolled.back@example.com')")
+		assert.NoError(t, err)
+	}
+	failure := errors.New("failure")
+
+	err := connectionPool.RunInTx(ctx, pgx.TxOptions{}, func(tx TransactionWrapper) error {
+		insert(tx)
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = connectionPool.RunInTx(ctx, pgx.TxOptions{}, func(tx TransactionWrapper) error {
+			insert(tx)
+			panic("boom")
+		})
+	})
+
+	var count int
+	err = connectionPool.QueryScalar(ctx, "SELECT COUNT(*) FROM users WHERE id = 911", &count, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestHealthCheckRunsQuery(t *testing.T) {
+	err := connectionPool.HealthCheck(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestHealthCheckFailsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := connectionPool.HealthCheck(ctx)
+
+	assert.ErrorContains(t, err, "health check")
+}
+
+func TestAcquirePinsConnection(t *testing.T) {
+	ctx := context.Background()
+	conn, err := connectionPool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Failed to acquire connection: %v", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock(42)")
+	assert.NoError(t, err)
+
+	var locked bool
+	err = conn.QueryRow(ctx, "SELECT pg_advisory_unlock(42)").Scan(&locked)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+}
+
+func TestExecReturningMapsRowsAndAffectedCount(t *testing.T) {
+	ctx := context.Background()
+	_, err := connectionPool.Exec(ctx, "CREATE TABLE notes (id SERIAL PRIMARY KEY, text VARCHAR(255) NOT NULL)")
+	if err != nil {
+		t.Fatalf("Failed to create notes table: %v", err)
+	}
+	type note struct {
+		NoteId int    `primaryKey:"id"`
+		Text   string `db:"text"`
+	}
+
+	var inserted []note
+	affectedRows, err := connectionPool.ExecReturning(ctx, "INSERT INTO notes (text) VALUES ($1), ($2) RETURNING *", &inserted, "first", "second")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affectedRows)
+	assert.Equal(t, []note{{NoteId: 1, Text: "first"}, {NoteId: 2, Text: "second"}}, inserted)
+}
+
+func TestExecReturningWithNilDestCountsAffectedRows(t *testing.T) {
+	ctx := context.Background()
+	_, err := connectionPool.Exec(ctx, "CREATE TABLE drafts (id SERIAL PRIMARY KEY, text VARCHAR(255) NOT NULL)")
+	if err != nil {
+		t.Fatalf("Failed to create drafts table: %v", err)
+	}
+
+	affectedRows, err := connectionPool.ExecReturning(ctx, "INSERT INTO drafts (text) VALUES ($1), ($2), ($3) RETURNING id", nil, "a", "b", "c")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), affectedRows)
+}
@@ -0,0 +1,41 @@
+package pool
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/raunlo/pgx-with-automapper/mapper"
+)
+
+// copier runs COPY of copyFromStructs on the pool or in the transaction
+type copier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// copyFromStructs copies slice of entities into the table with COPY protocol. Columns are derived from the db tags of
+// the entity, omitting columns generated by the database like inserts do.
+func copyFromStructs(ctx context.Context, c copier, tableName pgx.Identifier, src interface{}) (int64, error) {
+	srcValue := reflect.Indirect(reflect.ValueOf(src))
+	if !srcValue.IsValid() || srcValue.Kind() != reflect.Slice {
+		return 0, errors.New("src must be a slice of structs")
+	}
+	columns, err := mapper.InsertColumns(srcValue.Type().Elem())
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([][]any, srcValue.Len())
+	for i := range rows {
+		values, err := mapper.BindStruct(srcValue.Index(i).Interface())
+		if err != nil {
+			return 0, err
+		}
+		rows[i] = make([]any, len(columns))
+		for columnIndex, column := range columns {
+			rows[i][columnIndex] = values[column]
+		}
+	}
+	return c.CopyFrom(ctx, tableName, columns, pgx.CopyFromRows(rows))
+}
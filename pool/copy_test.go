@@ -0,0 +1,48 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStockStruct struct {
+	StockId  int    `primaryKey:"id,generated"`
+	Sku      string `db:"sku"`
+	Quantity int    `db:"quantity"`
+}
+
+func TestCopyFromStructsCopiesRows(t *testing.T) {
+	ctx := context.Background()
+	_, err := connectionPool.Exec(ctx, `
+        CREATE TABLE stock (
+            id SERIAL PRIMARY KEY,
+            sku VARCHAR(255) NOT NULL,
+            quantity INT NOT NULL
+        );
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create stock table: %v", err)
+	}
+
+	copiedRows, err := connectionPool.CopyFromStructs(ctx, pgx.Identifier{"stock"}, []testStockStruct{
+		{Sku: "chair", Quantity: 5},
+		{Sku: "table", Quantity: 2},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), copiedRows)
+
+	var res []testStockStruct
+	err = connectionPool.QueryList(ctx, "SELECT * FROM stock ORDER BY id", &res, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []testStockStruct{{StockId: 1, Sku: "chair", Quantity: 5}, {StockId: 2, Sku: "table", Quantity: 2}}, res)
+}
+
+func TestCopyFromStructsRejectsNonSlice(t *testing.T) {
+	_, err := connectionPool.CopyFromStructs(context.Background(), pgx.Identifier{"stock"}, testStockStruct{})
+
+	assert.ErrorContains(t, err, "src must be a slice of structs")
+}
@@ -0,0 +1,23 @@
+package pool
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// explain runs EXPLAIN of the statement and returns the plan lines joined with newlines. Plain EXPLAIN only plans the
+// statement, so inserts and updates are not executed.
+func explain(ctx context.Context, db querier, sql string, args ...any) (string, error) {
+	rows, err := db.Query(ctx, "EXPLAIN "+sql, args...)
+	if err != nil {
+		return "", errors.Wrap(err, "explain")
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", errors.Wrap(err, "explain")
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,31 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/raunlo/pgx-with-automapper/mapper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainReturnsPlanWithoutExecuting(t *testing.T) {
+	ctx := context.Background()
+	sql, args, err := mapper.BuildInsert("users", testUserStruct{UserId: 900, Name: "Planned", Email: "planned@example.com"})
+	assert.NoError(t, err)
+
+	plan, err := connectionPool.Explain(ctx, sql, args)
+
+	assert.NoError(t, err)
+	assert.Contains(t, plan, "Insert on users")
+	var count int
+	err = connectionPool.QueryScalar(ctx, "SELECT COUNT(*) FROM users WHERE id = 900", &count, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestExplainWithPositionalArgs(t *testing.T) {
+	plan, err := connectionPool.Explain(context.Background(), "SELECT * FROM users WHERE id = $1", 1)
+
+	assert.NoError(t, err)
+	assert.Contains(t, plan, "users")
+}
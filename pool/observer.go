@@ -0,0 +1,128 @@
+package pool
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// QueryObserver is called after each query with its SQL, duration and error, e.g. for latency histograms
+type QueryObserver func(sql string, duration time.Duration, err error)
+
+// WithQueryObserver calls observer after each query run on connections of the pool, including QueryOne, QueryList
+// and Exec. Tracer set by WithQueryTracer keeps tracing the queries regardless of the order of the options, including
+// its batch, copy, prepare, connect, acquire and release hooks.
+func WithQueryObserver(observer QueryObserver) Option {
+	return func(config *pgxpool.Config) {
+		config.ConnConfig.Tracer = &observerTracer{observer: observer, next: config.ConnConfig.Tracer}
+	}
+}
+
+// observerTracer times queries for QueryObserver and delegates tracing to the next tracer, forwarding the optional
+// tracer interfaces which the next tracer implements
+type observerTracer struct {
+	observer QueryObserver
+	next     pgx.QueryTracer
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql  string
+	time time.Time
+}
+
+func (t *observerTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if t.next != nil {
+		ctx = t.next.TraceQueryStart(ctx, conn, data)
+	}
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, time: time.Now()})
+}
+
+func (t *observerTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	if start, ok := ctx.Value(queryStartKey{}).(queryStart); ok {
+		t.observer(start.sql, time.Since(start.time), data.Err)
+	}
+	if t.next != nil {
+		t.next.TraceQueryEnd(ctx, conn, data)
+	}
+}
+
+func (t *observerTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	if next, ok := t.next.(pgx.BatchTracer); ok {
+		return next.TraceBatchStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+func (t *observerTracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	if next, ok := t.next.(pgx.BatchTracer); ok {
+		next.TraceBatchQuery(ctx, conn, data)
+	}
+}
+
+func (t *observerTracer) TraceBatchEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchEndData) {
+	if next, ok := t.next.(pgx.BatchTracer); ok {
+		next.TraceBatchEnd(ctx, conn, data)
+	}
+}
+
+func (t *observerTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	if next, ok := t.next.(pgx.CopyFromTracer); ok {
+		return next.TraceCopyFromStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+func (t *observerTracer) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	if next, ok := t.next.(pgx.CopyFromTracer); ok {
+		next.TraceCopyFromEnd(ctx, conn, data)
+	}
+}
+
+func (t *observerTracer) TracePrepareStart(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareStartData) context.Context {
+	if next, ok := t.next.(pgx.PrepareTracer); ok {
+		return next.TracePrepareStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+func (t *observerTracer) TracePrepareEnd(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareEndData) {
+	if next, ok := t.next.(pgx.PrepareTracer); ok {
+		next.TracePrepareEnd(ctx, conn, data)
+	}
+}
+
+func (t *observerTracer) TraceConnectStart(ctx context.Context, data pgx.TraceConnectStartData) context.Context {
+	if next, ok := t.next.(pgx.ConnectTracer); ok {
+		return next.TraceConnectStart(ctx, data)
+	}
+	return ctx
+}
+
+func (t *observerTracer) TraceConnectEnd(ctx context.Context, data pgx.TraceConnectEndData) {
+	if next, ok := t.next.(pgx.ConnectTracer); ok {
+		next.TraceConnectEnd(ctx, data)
+	}
+}
+
+func (t *observerTracer) TraceAcquireStart(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireStartData) context.Context {
+	if next, ok := t.next.(pgxpool.AcquireTracer); ok {
+		return next.TraceAcquireStart(ctx, pool, data)
+	}
+	return ctx
+}
+
+func (t *observerTracer) TraceAcquireEnd(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	if next, ok := t.next.(pgxpool.AcquireTracer); ok {
+		next.TraceAcquireEnd(ctx, pool, data)
+	}
+}
+
+func (t *observerTracer) TraceRelease(pool *pgxpool.Pool, data pgxpool.TraceReleaseData) {
+	if next, ok := t.next.(pgxpool.ReleaseTracer); ok {
+		next.TraceRelease(pool, data)
+	}
+}
@@ -0,0 +1,34 @@
+package pool
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Option customizes pgxpool configuration of pools created by NewDatabasePoolWithConfig
+type Option func(config *pgxpool.Config)
+
+// WithQueryTracer traces queries of all connections in the pool, e.g. with otelpgx for latency metrics. Observer set
+// by WithQueryObserver before this option keeps observing the queries.
+func WithQueryTracer(tracer pgx.QueryTracer) Option {
+	return func(config *pgxpool.Config) {
+		if observer, ok := config.ConnConfig.Tracer.(*observerTracer); ok {
+			for next, ok := observer.next.(*observerTracer); ok; next, ok = observer.next.(*observerTracer) {
+				observer = next
+			}
+			observer.next = tracer
+			return
+		}
+		config.ConnConfig.Tracer = tracer
+	}
+}
+
+// WithAfterConnect runs afterConnect on each new connection before it is used, e.g. to register enum and composite
+// types with conn.TypeMap().RegisterType or to set session settings
+func WithAfterConnect(afterConnect func(ctx context.Context, conn *pgx.Conn) error) Option {
+	return func(config *pgxpool.Config) {
+		config.AfterConnect = afterConnect
+	}
+}
@@ -0,0 +1,88 @@
+package pool
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+// defaultFatalSQLStates are retried when retrying is enabled without configuring SQLSTATEs. 57P01 (admin_shutdown)
+// is returned on connections terminated by a database restart.
+var defaultFatalSQLStates = []string{"57P01"}
+
+// retryPolicy retries operations failing with fatal SQLSTATE. Fatal errors close the connection, so pgxpool
+// acquires a fresh connection for the next attempt.
+type retryPolicy struct {
+	attempts  int // total attempts, the first one included
+	sqlStates map[string]struct{}
+}
+
+func newRetryPolicy(cfg DatabaseConfiguration) retryPolicy { // nolint:gocritic
+	policy := retryPolicy{attempts: 1}
+	if cfg.FatalErrorRetries == nil || *cfg.FatalErrorRetries <= 0 {
+		return policy
+	}
+	policy.attempts += *cfg.FatalErrorRetries
+
+	sqlStates := cfg.FatalSQLStates
+	if len(sqlStates) == 0 {
+		sqlStates = defaultFatalSQLStates
+	}
+	policy.sqlStates = make(map[string]struct{}, len(sqlStates))
+	for _, sqlState := range sqlStates {
+		policy.sqlStates[sqlState] = struct{}{}
+	}
+	return policy
+}
+
+// do runs operation until it succeeds, fails with non-fatal error or attempts run out
+func (r retryPolicy) do(ctx context.Context, operation func() error) error {
+	return r.run(ctx, r.isFatal, operation)
+}
+
+// doUnsent runs operation like do, but retries only errors for which pgconn.SafeToRetry holds. Statement which
+// reached the server may have been applied before the connection failed, so running it again could apply it twice.
+func (r retryPolicy) doUnsent(ctx context.Context, operation func() error) error {
+	return r.run(ctx, pgconn.SafeToRetry, operation)
+}
+
+func (r retryPolicy) run(ctx context.Context, retryable func(error) bool, operation func() error) error {
+	var err error
+	for attempt := 0; attempt < r.attempts; attempt++ {
+		if err = operation(); err == nil || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// doInto runs operation mapping its result into dest like do. Failed attempt may have mapped part of the rows, so
+// dest is restored to its value before the first attempt ahead of each retry, as slices are appended to.
+func (r retryPolicy) doInto(ctx context.Context, dest interface{}, operation func() error) error {
+	destination := reflect.ValueOf(dest)
+	if destination.Kind() != reflect.Ptr || destination.IsNil() {
+		return r.do(ctx, operation)
+	}
+	original := reflect.New(destination.Elem().Type()).Elem()
+	original.Set(destination.Elem())
+
+	attempt := 0
+	return r.do(ctx, func() error {
+		if attempt > 0 {
+			destination.Elem().Set(original)
+		}
+		attempt++
+		return operation()
+	})
+}
+
+func (r retryPolicy) isFatal(err error) bool {
+	var pgErr *pgconn.PgError
+	if err == nil || !errors.As(err, &pgErr) {
+		return false
+	}
+	_, fatal := r.sqlStates[pgErr.Code]
+	return fatal
+}
@@ -0,0 +1,165 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyRetriesAdminShutdown(t *testing.T) {
+	retries := 2
+	policy := newRetryPolicy(DatabaseConfiguration{FatalErrorRetries: &retries})
+	attempts := 0
+
+	err := policy.do(context.Background(), func() error {
+		attempts++
+		if attempts == 1 {
+			return &pgconn.PgError{Code: "57P01", Message: "terminating connection due to administrator command"}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryPolicyStopsAfterAttempts(t *testing.T) {
+	retries := 2
+	policy := newRetryPolicy(DatabaseConfiguration{FatalErrorRetries: &retries, FatalSQLStates: []string{"57P02"}})
+	attempts := 0
+
+	err := policy.do(context.Background(), func() error {
+		attempts++
+		return &pgconn.PgError{Code: "57P02"}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryPolicyDoesNotRetryOtherErrors(t *testing.T) {
+	retries := 2
+	policy := newRetryPolicy(DatabaseConfiguration{FatalErrorRetries: &retries})
+	attempts := 0
+
+	err := policy.do(context.Background(), func() error {
+		attempts++
+		return &pgconn.PgError{Code: "23505"}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicyIsDisabledByDefault(t *testing.T) {
+	policy := newRetryPolicy(DatabaseConfiguration{})
+	attempts := 0
+
+	err := policy.do(context.Background(), func() error {
+		attempts++
+		return &pgconn.PgError{Code: "57P01"}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+// unsentError is like errors of pgconn returned before the statement was sent
+type unsentError struct{}
+
+func (unsentError) Error() string { return "failed to write query" }
+
+func (unsentError) SafeToRetry() bool { return true }
+
+func TestRetryPolicyRetriesOnlyUnsentStatements(t *testing.T) {
+	retries := 2
+	policy := newRetryPolicy(DatabaseConfiguration{FatalErrorRetries: &retries})
+	attempts := 0
+
+	err := policy.doUnsent(context.Background(), func() error {
+		attempts++
+		if attempts == 1 {
+			return unsentError{}
+		}
+		return &pgconn.PgError{Code: "57P01"}
+	})
+
+	assert.Equal(t, &pgconn.PgError{Code: "57P01"}, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestConnectWithRetryRetriesWithBackoff(t *testing.T) {
+	retries, backoff := 3, time.Millisecond
+	cfg := DatabaseConfiguration{ConnectRetries: &retries, ConnectRetryBackoff: &backoff}
+	attempts := 0
+	start := time.Now()
+
+	err := connectWithRetry(cfg, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond) // 1ms + 2ms
+}
+
+func TestConnectWithRetryGivesUp(t *testing.T) {
+	retries, backoff := 2, time.Millisecond
+	cfg := DatabaseConfiguration{ConnectRetries: &retries, ConnectRetryBackoff: &backoff}
+	attempts := 0
+
+	err := connectWithRetry(cfg, func(ctx context.Context) error {
+		attempts++
+		return errors.New("connection refused")
+	})
+
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestConnectWithRetryLimitsAttemptsWithConnTimeout(t *testing.T) {
+	timeout := 50 * time.Millisecond
+	cfg := DatabaseConfiguration{ConnTimeout: &timeout}
+	var remaining time.Duration
+
+	err := connectWithRetry(cfg, func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		remaining = time.Until(deadline)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, remaining, timeout)
+}
+
+func TestRetryPolicyRestoresPartlyMappedDestination(t *testing.T) {
+	retries := 1
+	policy := newRetryPolicy(DatabaseConfiguration{FatalErrorRetries: &retries})
+	type tagged struct {
+		Tags []int
+	}
+	dest := []tagged{}
+	attempts := 0
+
+	err := policy.doInto(context.Background(), &dest, func() error {
+		attempts++
+		// rows mapped before the connection was terminated mid-stream
+		dest = append(dest, tagged{Tags: []int{1, 2}})
+		if attempts == 1 {
+			return &pgconn.PgError{Code: "57P01"}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []tagged{{Tags: []int{1, 2}}}, dest)
+}
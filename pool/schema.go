@@ -0,0 +1,43 @@
+package pool
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// txBeginner starts the transaction queryInSchema runs in. It is the pool, as Begin of pgx.Tx creates a savepoint.
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// queryInSchema runs the query in a transaction with search_path set to the schema and maps its result into dest like
+// QueryBatch does. search_path is set with SET LOCAL semantics, so it does not leak into other queries of the
+// connection.
+func queryInSchema(ctx context.Context, db txBeginner, schema string, sql string, dest interface{}, args ...any) (err error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		err = tx.Commit(ctx)
+	}()
+
+	if _, err = tx.Exec(ctx, "SELECT set_config('search_path', $1, true)", pgx.Identifier{schema}.Sanitize()); err != nil {
+		return errors.Wrap(err, "set search_path")
+	}
+	if dest == nil {
+		_, err = tx.Exec(ctx, sql, args...)
+		return err
+	}
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	return scanRows(ctx, rows, dest)
+}
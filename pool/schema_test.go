@@ -0,0 +1,30 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryInSchemaScopesSearchPath(t *testing.T) {
+	ctx := context.Background()
+	_, err := connectionPool.Exec(ctx, `
+        CREATE SCHEMA tenant_a;
+        CREATE TABLE tenant_a.users (id INT PRIMARY KEY, name VARCHAR(255) NOT NULL, email VARCHAR(255) NOT NULL);
+        INSERT INTO tenant_a.users (id, name, email) VALUES (7, 'Tenant User', 'tenant@example.com');
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create tenant schema: %v", err)
+	}
+
+	var res []testUserStruct
+	err = connectionPool.QueryInSchema(ctx, "tenant_a", "SELECT * FROM users", &res)
+	assert.NoError(t, err)
+	assert.Equal(t, []testUserStruct{{UserId: 7, Name: "Tenant User", Email: "tenant@example.com"}}, res)
+
+	var count int
+	err = connectionPool.QueryScalar(ctx, "SELECT COUNT(*) FROM users WHERE id = 7", &count, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,26 @@
+package pool
+
+import "context"
+
+// EntityQuerier runs queries mapping their results into entities. It is implemented by both Conn and
+// TransactionWrapper.
+type EntityQuerier interface {
+	QueryOne(ctx context.Context, sql string, dest interface{}, args ...any) error
+	QueryList(ctx context.Context, sql string, dest interface{}, args ...any) error
+}
+
+// QueryOneTyped runs the query and returns its result mapped into T like QueryOne does
+func QueryOneTyped[T any](ctx context.Context, q EntityQuerier, sql string, args ...any) (T, error) {
+	var result T
+	err := q.QueryOne(ctx, sql, &result, args...)
+	return result, err
+}
+
+// QueryListTyped runs the query and returns its result mapped into slice of T like QueryList does
+func QueryListTyped[T any](ctx context.Context, q EntityQuerier, sql string, args ...any) ([]T, error) {
+	var result []T
+	if err := q.QueryList(ctx, sql, &result, args...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
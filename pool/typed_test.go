@@ -0,0 +1,29 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/raunlo/pgx-with-automapper/mapper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryOneTypedReturnsEntity(t *testing.T) {
+	res, err := QueryOneTyped[testUserStruct](context.Background(), connectionPool, "SELECT * FROM users WHERE id = $1", 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testUserStruct{UserId: 1, Name: "John Doe", Email: "john.doe@example.com"}, res)
+}
+
+func TestQueryOneTypedWhichReturnsEmpty(t *testing.T) {
+	_, err := QueryOneTyped[testUserStruct](context.Background(), connectionPool, "SELECT * FROM users WHERE id = $1", 2)
+
+	assert.ErrorIs(t, err, mapper.ErrNoRows)
+}
+
+func TestQueryListTypedReturnsEntities(t *testing.T) {
+	res, err := QueryListTyped[*testUserStruct](context.Background(), connectionPool, "SELECT * FROM users WHERE id = $1", 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*testUserStruct{{UserId: 1, Name: "John Doe", Email: "john.doe@example.com"}}, res)
+}